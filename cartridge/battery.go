@@ -0,0 +1,59 @@
+package cartridge
+
+import "fmt"
+
+// Battery is implemented by the mappers whose memory survives turning the
+// game boy off, Save returns what goes in the .sav file and Load takes it
+// back, Memory is the part of the save the game writes (the ram without the
+// clock footer) so it can be told when there is something new to save
+type Battery interface {
+	Save() []uint8
+	Load(data []uint8) error
+	Memory() []uint8
+}
+
+// SaveSizeError is returned by Load when the save doesnt fit the cartridge
+type SaveSizeError struct {
+	Expected int
+	Got      int
+}
+
+func (err *SaveSizeError) Error() string {
+	return fmt.Sprintf("cartridge: save is %d bytes but the cartridge has %d", err.Got, err.Expected)
+}
+
+func saveRAM(ram []uint8) []uint8 {
+	return append([]uint8(nil), ram...)
+}
+
+func loadRAM(ram []uint8, data []uint8) error {
+	if len(data) != len(ram) {
+		return &SaveSizeError{Expected: len(ram), Got: len(data)}
+	}
+	copy(ram, data)
+	return nil
+}
+
+func (m *romOnly) Memory() []uint8 {
+	return m.ram
+}
+
+func (m *romOnly) Save() []uint8 {
+	return saveRAM(m.ram)
+}
+
+func (m *romOnly) Load(data []uint8) error {
+	return loadRAM(m.ram, data)
+}
+
+func (m *mbc1) Memory() []uint8 {
+	return m.ram
+}
+
+func (m *mbc1) Save() []uint8 {
+	return saveRAM(m.ram)
+}
+
+func (m *mbc1) Load(data []uint8) error {
+	return loadRAM(m.ram, data)
+}
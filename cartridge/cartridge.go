@@ -0,0 +1,87 @@
+package cartridge
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+)
+
+const (
+	ROM_BANK_SIZE = 0x4000
+	RAM_BANK_SIZE = 0x2000
+)
+
+var ErrTooSmall = errors.New("cartridge: rom is smaller than the header")
+
+// ChecksumError says which checksum of the header doesnt match the rom
+type ChecksumError struct {
+	// "header" or "global"
+	Checksum string
+	Expected uint16
+	Computed uint16
+}
+
+func (err *ChecksumError) Error() string {
+	return fmt.Sprintf("cartridge: %s checksum is 0x%X but the rom adds up to 0x%X", err.Checksum, err.Expected, err.Computed)
+}
+
+// Cartridge is a rom dump (.gb or .gbc) and its parsed header
+type Cartridge struct {
+	Header Header
+	ROM    []uint8
+	// where Load read it from, empty for the ones made with Parse
+	Path string
+}
+
+// Load reads a rom from disk, see Parse
+func Load(path string) (*Cartridge, error) {
+	rom, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cartridge, err := Parse(rom)
+	if err != nil {
+		return nil, err
+	}
+	cartridge.Path = path
+	return cartridge, nil
+}
+
+// Parse reads the header of the rom, the checksums are not checked here
+// because plenty of homebrew and hacks get them wrong and still run, see
+// CheckHeader and CheckGlobal
+func Parse(rom []uint8) (*Cartridge, error) {
+	if len(rom) < HEADER_END {
+		return nil, ErrTooSmall
+	}
+	return &Cartridge{Header: parseHeader(rom), ROM: rom}, nil
+}
+
+// CheckHeader returns a *ChecksumError if the header checksum is wrong, the
+// boot rom locks up when that happens
+func (c *Cartridge) CheckHeader() error {
+	if computed := headerChecksum(c.ROM); computed != c.Header.HeaderChecksum {
+		return &ChecksumError{Checksum: "header", Expected: uint16(c.Header.HeaderChecksum), Computed: uint16(computed)}
+	}
+	return nil
+}
+
+// CheckGlobal returns a *ChecksumError if the checksum of the whole rom is
+// wrong, the hardware never checks it but it tells a bad dump apart
+func (c *Cartridge) CheckGlobal() error {
+	if computed := globalChecksum(c.ROM); computed != c.Header.GlobalChecksum {
+		return &ChecksumError{Checksum: "global", Expected: c.Header.GlobalChecksum, Computed: computed}
+	}
+	return nil
+}
+
+// the hashes the rom databases (no-intro...) use to identify dumps
+func (c *Cartridge) CRC32() uint32 {
+	return crc32.ChecksumIEEE(c.ROM)
+}
+
+func (c *Cartridge) SHA1() [sha1.Size]uint8 {
+	return sha1.Sum(c.ROM)
+}
@@ -0,0 +1,217 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// a 32kb rom with the header bytes given and valid checksums
+func newTestROM(size int, header map[uint16]uint8) []uint8 {
+	rom := make([]uint8, size)
+	for address, value := range header {
+		rom[address] = value
+	}
+	rom[HEADER_CHECKSUM_ADDRESS] = headerChecksum(rom)
+	checksum := globalChecksum(rom)
+	rom[GLOBAL_CHECKSUM_ADDRESS] = uint8(checksum >> 8)
+	rom[GLOBAL_CHECKSUM_ADDRESS+1] = uint8(checksum)
+	return rom
+}
+
+func withTitle(header map[uint16]uint8, title string) map[uint16]uint8 {
+	for i, char := range []uint8(title) {
+		header[TITLE_ADDRESS+uint16(i)] = char
+	}
+	return header
+}
+
+func TestParseHeader(t *testing.T) {
+	type test struct {
+		title    string
+		header   map[uint16]uint8
+		expected Header
+	}
+
+	tests := []test{
+		{
+			title: "dmg game with a 16 byte title",
+			header: withTitle(map[uint16]uint8{
+				TYPE_ADDRESS:         0x03,
+				ROM_SIZE_ADDRESS:     0x05,
+				RAM_SIZE_ADDRESS:     0x03,
+				DESTINATION_ADDRESS:  0x01,
+				OLD_LICENSEE_ADDRESS: 0x01,
+				VERSION_ADDRESS:      0x02,
+			}, "SIXTEEN CHARS OK"),
+			expected: Header{
+				Title: "SIXTEEN CHARS OK",
+				// the last character is where the cgb flag went later
+				CGBFlag:     'K',
+				Type:        0x03,
+				ROMSize:     0x05,
+				RAMSize:     0x03,
+				Destination: 0x01,
+				OldLicensee: 0x01,
+				NewLicensee: "\x00\x00",
+				Version:     0x02,
+			},
+		},
+		{
+			title: "cgb game with a manufacturer code",
+			header: withTitle(map[uint16]uint8{
+				CGB_FLAG_ADDRESS:         0xC0,
+				SGB_FLAG_ADDRESS:         0x03,
+				NEW_LICENSEE_ADDRESS:     '0',
+				NEW_LICENSEE_ADDRESS + 1: '1',
+				OLD_LICENSEE_ADDRESS:     USE_NEW_LICENSEE,
+				TYPE_ADDRESS:             0x1B,
+			}, "POKEMON_SLVAAXE"),
+			expected: Header{
+				Title:        "POKEMON_SLV",
+				Manufacturer: "AAXE",
+				CGBFlag:      0xC0,
+				SGBFlag:      0x03,
+				Type:         0x1B,
+				OldLicensee:  USE_NEW_LICENSEE,
+				NewLicensee:  "01",
+			},
+		},
+		{
+			title: "cgb game whose title runs into the manufacturer code",
+			header: withTitle(map[uint16]uint8{
+				CGB_FLAG_ADDRESS: 0x80,
+			}, "ZELDA DX gb"),
+			expected: Header{
+				Title:       "ZELDA DX gb",
+				CGBFlag:     0x80,
+				NewLicensee: "\x00\x00",
+			},
+		},
+	}
+
+	for _, unit_test := range tests {
+		cartridge, err := Parse(newTestROM(0x8000, unit_test.header))
+		if err != nil {
+			t.Errorf("failed : %s %v", unit_test.title, err)
+			continue
+		}
+		// the checksums are whatever newTestROM came up with
+		unit_test.expected.HeaderChecksum = cartridge.Header.HeaderChecksum
+		unit_test.expected.GlobalChecksum = cartridge.Header.GlobalChecksum
+		if cartridge.Header != unit_test.expected {
+			t.Errorf("failed : %s expected : %+v got : %+v", unit_test.title, unit_test.expected, cartridge.Header)
+		} else {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
+
+func TestHeaderFields(t *testing.T) {
+	header := Header{CGBFlag: 0x80, SGBFlag: 0x03, OldLicensee: 0x33, NewLicensee: "01", ROMSize: 0x04, RAMSize: 0x03}
+	if header.CGB() != CGBCompatible || !header.SGB() {
+		t.Errorf("failed : expected cgb compatible and sgb")
+	}
+	if header.ROMBytes() != 512*1024 || header.ROMBanks() != 32 || header.RAMBytes() != 32*1024 {
+		t.Errorf("failed : expected 512kb rom in 32 banks and 32kb ram got : %d %d %d", header.ROMBytes(), header.ROMBanks(), header.RAMBytes())
+	}
+	if header.Licensee() != "Nintendo Research & Development 1" {
+		t.Errorf("failed : new licensee got : %s", header.Licensee())
+	}
+
+	// without the 0x33 the sgb flag is ignored
+	header = Header{SGBFlag: 0x03, OldLicensee: 0x01}
+	if header.SGB() || header.Licensee() != "Nintendo" || header.CGB() != CGBNone {
+		t.Errorf("failed : old licensee expected no sgb and nintendo")
+	}
+	if header := (Header{OldLicensee: 0x02}); header.Licensee() != "unknown (0x02)" {
+		t.Errorf("failed : expected unknown licensee got : %s", header.Licensee())
+	}
+
+	sizes := map[uint8]int{0x00: 0, 0x01: 0, 0x02: 8 * 1024, 0x03: 32 * 1024, 0x04: 128 * 1024, 0x05: 64 * 1024}
+	for code, size := range sizes {
+		header := Header{RAMSize: code}
+		if header.RAMBytes() != size {
+			t.Errorf("failed : ram code 0x%02X expected : %d got : %d", code, size, header.RAMBytes())
+		}
+	}
+	for code := range uint8(9) {
+		header := Header{ROMSize: code}
+		if header.ROMBanks() != 2<<code {
+			t.Errorf("failed : rom code 0x%02X expected : %d banks got : %d", code, 2<<code, header.ROMBanks())
+		}
+	}
+}
+
+func TestTypeNames(t *testing.T) {
+	names := map[Type]string{
+		0x00: "ROM ONLY",
+		0x03: "MBC1+RAM+BATTERY",
+		0x06: "MBC2+BATTERY",
+		0x09: "ROM+RAM+BATTERY",
+		0x10: "MBC3+TIMER+RAM+BATTERY",
+		0x1E: "MBC5+RUMBLE+RAM+BATTERY",
+		0x22: "MBC7+SENSOR+RUMBLE+RAM+BATTERY",
+		0xFC: "POCKET CAMERA",
+		0xFF: "HuC1+RAM+BATTERY",
+		0x04: "UNKNOWN 0x04",
+	}
+	for code, name := range names {
+		if code.String() != name {
+			t.Errorf("failed : 0x%02X expected : %s got : %s", uint8(code), name, code)
+		}
+	}
+}
+
+func TestChecksums(t *testing.T) {
+	rom := newTestROM(0x8000, withTitle(map[uint16]uint8{}, "TEST"))
+	cartridge, _ := Parse(rom)
+	if cartridge.CheckHeader() != nil || cartridge.CheckGlobal() != nil {
+		t.Errorf("failed : expected valid checksums got : %v %v", cartridge.CheckHeader(), cartridge.CheckGlobal())
+	}
+
+	// the global checksum doesnt cover itself but it does cover the header
+	// checksum
+	rom[0x4000] = 0x01
+	var checksum_error *ChecksumError
+	if err := cartridge.CheckGlobal(); !errors.As(err, &checksum_error) || checksum_error.Computed != checksum_error.Expected+1 {
+		t.Errorf("failed : expected a global checksum error got : %v", err)
+	}
+	if cartridge.CheckHeader() != nil {
+		t.Errorf("failed : the header checksum doesnt cover 0x4000")
+	}
+	rom[VERSION_ADDRESS] = 0x01
+	if err := cartridge.CheckHeader(); !errors.As(err, &checksum_error) || checksum_error.Checksum != "header" {
+		t.Errorf("failed : expected a header checksum error got : %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	if _, err := Parse(make([]uint8, 0x100)); err != ErrTooSmall {
+		t.Errorf("failed : expected ErrTooSmall got : %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.gb")
+	if err := os.WriteFile(path, make([]uint8, 0x8000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cartridge, err := Load(path)
+	if err != nil {
+		t.Fatalf("failed : load %v", err)
+	}
+	if cartridge.Path != path {
+		t.Errorf("failed : expected the path of the rom got : %s", cartridge.Path)
+	}
+	// 32kb of zeros
+	if cartridge.CRC32() != 0x011FFCA6 {
+		t.Errorf("failed : crc32 got : 0x%08X", cartridge.CRC32())
+	}
+	if sha := fmt.Sprintf("%x", cartridge.SHA1()); sha != "5188431849b4613152fd7bdba6a3ff0a4fd6424b" {
+		t.Errorf("failed : sha1 got : %s", sha)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.gb")); err == nil {
+		t.Errorf("failed : expected an error for a missing file")
+	}
+}
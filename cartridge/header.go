@@ -0,0 +1,225 @@
+package cartridge
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the header lives at 0x0100-0x014F in every rom
+// https://gbdev.io/pandocs/The_Cartridge_Header.html
+const (
+	LOGO_ADDRESS            = 0x0104
+	TITLE_ADDRESS           = 0x0134
+	MANUFACTURER_ADDRESS    = 0x013F
+	CGB_FLAG_ADDRESS        = 0x0143
+	NEW_LICENSEE_ADDRESS    = 0x0144
+	SGB_FLAG_ADDRESS        = 0x0146
+	TYPE_ADDRESS            = 0x0147
+	ROM_SIZE_ADDRESS        = 0x0148
+	RAM_SIZE_ADDRESS        = 0x0149
+	DESTINATION_ADDRESS     = 0x014A
+	OLD_LICENSEE_ADDRESS    = 0x014B
+	VERSION_ADDRESS         = 0x014C
+	HEADER_CHECKSUM_ADDRESS = 0x014D
+	GLOBAL_CHECKSUM_ADDRESS = 0x014E
+	HEADER_END              = 0x0150
+)
+
+const (
+	// the old licensee byte says the real one is in the new licensee field
+	USE_NEW_LICENSEE = 0x33
+	// SGB_FLAG_ADDRESS has to hold this for the super game boy functions to
+	// be enabled
+	SGB_SUPPORTED = 0x03
+)
+
+// the nintendo logo the boot rom scrolls down, it checks the cartridge has
+// the same bytes before running it
+var LOGO = [48]uint8{
+	0xCE, 0xED, 0x66, 0x66, 0xCC, 0x0D, 0x00, 0x0B, 0x03, 0x73, 0x00, 0x83,
+	0x00, 0x0C, 0x00, 0x0D, 0x00, 0x08, 0x11, 0x1F, 0x88, 0x89, 0x00, 0x0E,
+	0xDC, 0xCC, 0x6E, 0xE6, 0xDD, 0xDD, 0xD9, 0x99, 0xBB, 0xBB, 0x67, 0x63,
+	0x6E, 0x0E, 0xEC, 0xCC, 0xDD, 0xDC, 0x99, 0x9F, 0xBB, 0xB9, 0x33, 0x3E,
+}
+
+type CGBSupport uint8
+
+const (
+	// made for the original game boy, the cgb runs it in compatibility mode
+	CGBNone CGBSupport = iota
+	// runs on both, with colors on the cgb (0x80)
+	CGBCompatible
+	// only runs on the cgb (0xC0)
+	CGBOnly
+)
+
+func (support CGBSupport) String() string {
+	switch support {
+	case CGBCompatible:
+		{
+			return "compatible"
+		}
+	case CGBOnly:
+		{
+			return "only"
+		}
+	}
+	return "no"
+}
+
+type Header struct {
+	Title string
+	// 4 characters that only the later cgb games have, empty otherwise
+	Manufacturer string
+	CGBFlag      uint8
+	SGBFlag      uint8
+	Type         Type
+	// the codes as they are in the header, see ROMBytes and RAMBytes
+	ROMSize uint8
+	RAMSize uint8
+	// 0x00 japan, 0x01 everywhere else
+	Destination uint8
+	OldLicensee uint8
+	// 2 ascii characters, only used when OldLicensee is USE_NEW_LICENSEE
+	NewLicensee    string
+	Version        uint8
+	HeaderChecksum uint8
+	// the only big endian value in the header
+	GlobalChecksum uint16
+}
+
+// parses the header out of the rom, the rom has to be at least HEADER_END
+// bytes long
+func parseHeader(rom []uint8) Header {
+	header := Header{
+		CGBFlag:        rom[CGB_FLAG_ADDRESS],
+		SGBFlag:        rom[SGB_FLAG_ADDRESS],
+		Type:           Type(rom[TYPE_ADDRESS]),
+		ROMSize:        rom[ROM_SIZE_ADDRESS],
+		RAMSize:        rom[RAM_SIZE_ADDRESS],
+		Destination:    rom[DESTINATION_ADDRESS],
+		OldLicensee:    rom[OLD_LICENSEE_ADDRESS],
+		NewLicensee:    string(rom[NEW_LICENSEE_ADDRESS : NEW_LICENSEE_ADDRESS+2]),
+		Version:        rom[VERSION_ADDRESS],
+		HeaderChecksum: rom[HEADER_CHECKSUM_ADDRESS],
+		GlobalChecksum: uint16(rom[GLOBAL_CHECKSUM_ADDRESS])<<8 | uint16(rom[GLOBAL_CHECKSUM_ADDRESS+1]),
+	}
+
+	// the title used to take 16 bytes, the cgb took the last one for its
+	// flag and the later games the 4 before it for the manufacturer code
+	end := CGB_FLAG_ADDRESS + 1
+	if header.CGB() != CGBNone {
+		end = CGB_FLAG_ADDRESS
+		if manufacturer := rom[MANUFACTURER_ADDRESS:CGB_FLAG_ADDRESS]; isManufacturer(manufacturer) {
+			header.Manufacturer = string(manufacturer)
+			end = MANUFACTURER_ADDRESS
+		}
+	}
+	title := rom[TITLE_ADDRESS:end]
+	if zero := strings.IndexByte(string(title), 0); zero >= 0 {
+		title = title[:zero]
+	}
+	header.Title = strings.TrimRight(string(title), " ")
+	return header
+}
+
+// manufacturer codes are 4 upper case letters or digits, older cgb games
+// have the end of the title there instead
+func isManufacturer(code []uint8) bool {
+	for _, char := range code {
+		if (char < 'A' || char > 'Z') && (char < '0' || char > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Header) CGB() CGBSupport {
+	switch h.CGBFlag {
+	case 0x80:
+		{
+			return CGBCompatible
+		}
+	case 0xC0:
+		{
+			return CGBOnly
+		}
+	}
+	return CGBNone
+}
+
+// the super game boy functions also need the old licensee to be 0x33
+func (h *Header) SGB() bool {
+	return h.SGBFlag == SGB_SUPPORTED && h.OldLicensee == USE_NEW_LICENSEE
+}
+
+// 32kb shifted by the code, 0x00 is 2 banks of 16kb and 0x08 is 512 banks
+func (h *Header) ROMBytes() int {
+	if h.ROMSize > 0x08 {
+		return 0
+	}
+	return 0x8000 << h.ROMSize
+}
+
+func (h *Header) ROMBanks() int {
+	return h.ROMBytes() / ROM_BANK_SIZE
+}
+
+// the amount of external ram, MBC2 has its ram built in so it says 0 here
+func (h *Header) RAMBytes() int {
+	switch h.RAMSize {
+	case 0x02:
+		{
+			return 0x2000
+		}
+	case 0x03:
+		{
+			return 0x8000
+		}
+	case 0x04:
+		{
+			return 0x20000
+		}
+	case 0x05:
+		{
+			return 0x10000
+		}
+	}
+	return 0
+}
+
+func (h *Header) Licensee() string {
+	if h.OldLicensee == USE_NEW_LICENSEE {
+		if name, ok := newLicensees[h.NewLicensee]; ok {
+			return name
+		}
+		return fmt.Sprintf("unknown (%q)", h.NewLicensee)
+	}
+	if name, ok := oldLicensees[h.OldLicensee]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (0x%02X)", h.OldLicensee)
+}
+
+// the checksum the boot rom checks before running the game, it covers
+// 0x0134-0x014C
+func headerChecksum(rom []uint8) uint8 {
+	var checksum uint8
+	for _, value := range rom[TITLE_ADDRESS:HEADER_CHECKSUM_ADDRESS] {
+		checksum = checksum - value - 1
+	}
+	return checksum
+}
+
+// the sum of every byte in the rom except the checksum itself, nothing on the
+// hardware checks it
+func globalChecksum(rom []uint8) uint16 {
+	var checksum uint16
+	for address, value := range rom {
+		if address == GLOBAL_CHECKSUM_ADDRESS || address == GLOBAL_CHECKSUM_ADDRESS+1 {
+			continue
+		}
+		checksum += uint16(value)
+	}
+	return checksum
+}
@@ -0,0 +1,170 @@
+package cartridge
+
+import "github.com/chilepikmin/gamegorl/memory"
+
+// Infrared is implemented by the mappers with an infrared led and sensor,
+// the frontend says when light reaches the sensor and hears about the led
+type Infrared interface {
+	ReceiveLight(on bool)
+	OnLight(listener func(on bool))
+}
+
+const (
+	// written to 0x0000-0x1FFF it puts the ir register at 0xA000-0xBFFF
+	HUC_SELECT_IR = 0x0E
+	// what the ir register reads, bit 0 is set while light is received
+	HUC_IR_DARK  = 0xC0
+	HUC_IR_LIGHT = 0xC1
+)
+
+// the infrared port of the hudson mappers, shared by HuC1 and HuC3
+type infrared struct {
+	receiving bool
+	led       bool
+	listeners []func(on bool)
+}
+
+func (ir *infrared) ReceiveLight(on bool) {
+	ir.receiving = on
+}
+
+func (ir *infrared) OnLight(listener func(on bool)) {
+	ir.listeners = append(ir.listeners, listener)
+}
+
+func (ir *infrared) read() uint8 {
+	if ir.receiving {
+		return HUC_IR_LIGHT
+	}
+	return HUC_IR_DARK
+}
+
+// bit 0 turns the led on
+func (ir *infrared) write(value uint8) {
+	on := value&0b1 != 0
+	if on == ir.led {
+		return
+	}
+	ir.led = on
+	for _, listener := range ir.listeners {
+		listener(on)
+	}
+}
+
+// HuC1, hudson's take on MBC1 with an infrared port in place of the ram
+// enable, up to 1MB of rom and 32kb of ram
+// https://gbdev.io/pandocs/HuC1.html
+type huc1 struct {
+	infrared
+	rom       []uint8
+	ram       []uint8
+	rom_banks int
+
+	// 0x0000-0x1FFF, 0x0E shows the ir register and anything else the ram
+	ir_selected bool
+	// 0x2000-0x3FFF, 6 bits
+	rom_bank uint8
+	// 0x4000-0x5FFF, 2 bits
+	ram_bank uint8
+}
+
+func newHuC1(cartridge *Cartridge) *huc1 {
+	return &huc1{
+		rom:       cartridge.ROM,
+		ram:       make([]uint8, cartridge.Header.RAMBytes()),
+		rom_banks: romBanks(cartridge.ROM),
+		rom_bank:  1,
+	}
+}
+
+func (m *huc1) highBank() int {
+	return int(m.rom_bank) % m.rom_banks
+}
+
+func (m *huc1) ramOffset(address uint16) int {
+	return (int(m.ram_bank)*RAM_BANK_SIZE + int(address-memory.EXTERNAL_RAM_START)) % len(m.ram)
+}
+
+func (m *huc1) Read(address uint16) uint8 {
+	switch {
+	case address < 0x4000:
+		{
+			return readROM(m.rom, int(address))
+		}
+	case address < memory.VRAM_START:
+		{
+			return readROM(m.rom, m.highBank()*ROM_BANK_SIZE+int(address-0x4000))
+		}
+	}
+	if m.ir_selected {
+		return m.infrared.read()
+	}
+	if len(m.ram) == 0 {
+		return memory.OPEN_BUS
+	}
+	return m.ram[m.ramOffset(address)]
+}
+
+func (m *huc1) Write(address uint16, value uint8) {
+	switch {
+	case address < 0x2000:
+		{
+			m.ir_selected = value&0x0F == HUC_SELECT_IR
+		}
+	case address < 0x4000:
+		{
+			m.rom_bank = value & 0x3F
+			if m.rom_bank == 0 {
+				m.rom_bank = 1
+			}
+		}
+	case address < 0x6000:
+		{
+			m.ram_bank = value & 0b11
+		}
+	case address < memory.VRAM_START:
+		{
+			// nothing is wired to 0x6000-0x7FFF
+		}
+	default:
+		{
+			if m.ir_selected {
+				m.infrared.write(value)
+				return
+			}
+			if len(m.ram) != 0 {
+				m.ram[m.ramOffset(address)] = value
+			}
+		}
+	}
+}
+
+// the rom bank mapped at the address, or the ram bank for 0xA000-0xBFFF
+func (m *huc1) Bank(address uint16) uint16 {
+	switch {
+	case address < 0x4000:
+		{
+			return 0
+		}
+	case address < memory.VRAM_START:
+		{
+			return uint16(m.highBank())
+		}
+	}
+	if len(m.ram) == 0 {
+		return 0
+	}
+	return uint16(m.ramOffset(address) / RAM_BANK_SIZE)
+}
+
+func (m *huc1) Save() []uint8 {
+	return saveRAM(m.ram)
+}
+
+func (m *huc1) Memory() []uint8 {
+	return m.ram
+}
+
+func (m *huc1) Load(data []uint8) error {
+	return loadRAM(m.ram, data)
+}
@@ -0,0 +1,52 @@
+package cartridge
+
+import "testing"
+
+func TestHuC1(t *testing.T) {
+	mbc := newHuC1(newBankedROM(64, 0xFF, 0x03))
+	for bank := range 256 {
+		mbc.Write(0x2000, uint8(bank))
+		expected := bank & 0x3F
+		if expected == 0 {
+			expected = 1
+		}
+		if got := int(mbc.Read(0x4000)); got != expected || int(mbc.Bank(0x4000)) != expected {
+			t.Errorf("failed : rom bank 0x%02X expected : %d got : %d", bank, expected, got)
+		}
+	}
+
+	// the ram doesnt need to be enabled
+	for bank := range 4 {
+		mbc.Write(0x4000, uint8(bank))
+		mbc.Write(0xA000, uint8(0x40+bank))
+	}
+	mbc.Write(0x4000, 0x02)
+	if value := mbc.Read(0xA000); value != 0x42 || mbc.Bank(0xA000) != 2 {
+		t.Errorf("failed : expected ram bank 2 got : 0x%02X", value)
+	}
+
+	leds := []bool{}
+	mbc.OnLight(func(on bool) {
+		leds = append(leds, on)
+	})
+	mbc.Write(0x0000, HUC_SELECT_IR)
+	if value := mbc.Read(0xA000); value != HUC_IR_DARK {
+		t.Errorf("failed : expected no light got : 0x%02X", value)
+	}
+	mbc.ReceiveLight(true)
+	if value := mbc.Read(0xA000); value != HUC_IR_LIGHT {
+		t.Errorf("failed : expected light got : 0x%02X", value)
+	}
+	mbc.Write(0xA000, 0x01)
+	mbc.Write(0xA000, 0x01)
+	mbc.Write(0xA000, 0x00)
+	if len(leds) != 2 || !leds[0] || leds[1] {
+		t.Errorf("failed : expected the led on and off got : %v", leds)
+	}
+
+	// back to the ram, the ir writes didnt touch it
+	mbc.Write(0x0000, 0x0A)
+	if value := mbc.Read(0xA000); value != 0x42 {
+		t.Errorf("failed : expected the ram back got : 0x%02X", value)
+	}
+}
@@ -0,0 +1,315 @@
+package cartridge
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+// Speaker is implemented by the mappers that can beep on their own, the
+// listeners get the number of the tone the game asked for
+type Speaker interface {
+	OnTone(listener func(tone uint8))
+}
+
+// what 0x0000-0x1FFF puts at 0xA000-0xBFFF
+const (
+	HUC3_RAM_READ     = 0x0
+	HUC3_RAM          = 0xA
+	HUC3_RTC_COMMAND  = 0xB
+	HUC3_RTC_RESPONSE = 0xC
+	HUC3_RTC_READY    = 0xD
+	HUC3_IR           = HUC_SELECT_IR
+)
+
+// the commands written in the upper nibble in HUC3_RTC_COMMAND mode, the
+// lower nibble is the argument
+const (
+	HUC3_READ         = 0x1
+	HUC3_WRITE        = 0x3
+	HUC3_ADDRESS_LOW  = 0x4
+	HUC3_ADDRESS_HIGH = 0x5
+	HUC3_EXTENDED     = 0x6
+
+	// the arguments of HUC3_EXTENDED
+	HUC3_LATCH_TIME = 0x0
+	HUC3_SET_TIME   = 0x1
+	HUC3_STATUS     = 0x2
+	HUC3_PLAY_TONE  = 0xE
+
+	// the nibble of the rtc memory with the tone to play
+	HUC3_TONE_ADDRESS = 0x27
+
+	// minutes and days as 32 bit little endian values, the unix time of the
+	// save and the rtc memory (the alarm and the tone) two nibbles a byte
+	HUC3_FOOTER_SIZE = 16 + HUC3_RTC_MEMORY_SIZE/2
+
+	HUC3_RTC_MEMORY_SIZE = 256
+)
+
+// HuC3, hudson's mapper with a clock, an infrared port and a speaker, up
+// to 2MB of rom and 32kb of ram, the clock is talked to a nibble at a time
+// through commands written to 0xA000
+// https://gbdev.io/pandocs/HuC3.html
+type huc3 struct {
+	infrared
+	rom       []uint8
+	ram       []uint8
+	rom_banks int
+
+	// 0x0000-0x1FFF
+	mode uint8
+	// 0x2000-0x3FFF, 7 bits
+	rom_bank uint8
+	// 0x4000-0x5FFF, 2 bits
+	ram_bank uint8
+
+	// the clock counts minutes and days from base, the game sees them
+	// through 256 nibbles of rtc memory
+	clock       Clock
+	base        time.Time
+	rtc_memory  [HUC3_RTC_MEMORY_SIZE]uint8
+	rtc_address uint8
+	command     uint8
+	response    uint8
+	tones       []func(tone uint8)
+}
+
+func newHuC3(cartridge *Cartridge) *huc3 {
+	clock := systemClock{}
+	return &huc3{
+		rom:       cartridge.ROM,
+		ram:       make([]uint8, cartridge.Header.RAMBytes()),
+		rom_banks: romBanks(cartridge.ROM),
+		rom_bank:  1,
+		clock:     clock,
+		base:      clock.Now(),
+	}
+}
+
+func (m *huc3) SetClock(clock Clock) {
+	elapsed := m.clock.Now().Sub(m.base)
+	m.clock = clock
+	m.base = clock.Now().Add(-elapsed)
+}
+
+func (m *huc3) OnTone(listener func(tone uint8)) {
+	m.tones = append(m.tones, listener)
+}
+
+// the minutes of the current day and the days, 12 bits each
+func (m *huc3) time() (minutes, days uint16) {
+	elapsed := m.clock.Now().Sub(m.base)
+	return uint16(elapsed / time.Minute % (24 * 60)), uint16(elapsed/(24*time.Hour)) & 0xFFF
+}
+
+func (m *huc3) setTime(minutes, days uint16) {
+	elapsed := time.Duration(days)*24*time.Hour + time.Duration(minutes)*time.Minute
+	m.base = m.clock.Now().Add(-elapsed)
+}
+
+// the time goes in nibbles 0x00-0x05 of the rtc memory, lowest nibble first
+func (m *huc3) latchTime() {
+	minutes, days := m.time()
+	for i := range 3 {
+		m.rtc_memory[i] = uint8(minutes>>(4*i)) & 0x0F
+		m.rtc_memory[3+i] = uint8(days>>(4*i)) & 0x0F
+	}
+}
+
+func (m *huc3) loadTime() {
+	minutes, days := uint16(0), uint16(0)
+	for i := range 3 {
+		minutes |= uint16(m.rtc_memory[i]) << (4 * i)
+		days |= uint16(m.rtc_memory[3+i]) << (4 * i)
+	}
+	m.setTime(minutes%(24*60), days)
+}
+
+func (m *huc3) runCommand(value uint8) {
+	m.command = value >> 4 & 0b111
+	argument := value & 0x0F
+	switch m.command {
+	case HUC3_READ:
+		{
+			m.response = m.rtc_memory[m.rtc_address]
+			m.rtc_address++
+		}
+	case HUC3_WRITE:
+		{
+			m.rtc_memory[m.rtc_address] = argument
+			m.rtc_address++
+		}
+	case HUC3_ADDRESS_LOW:
+		{
+			m.rtc_address = m.rtc_address&0xF0 | argument
+		}
+	case HUC3_ADDRESS_HIGH:
+		{
+			m.rtc_address = m.rtc_address&0x0F | argument<<4
+		}
+	case HUC3_EXTENDED:
+		{
+			switch argument {
+			case HUC3_LATCH_TIME:
+				{
+					m.latchTime()
+				}
+			case HUC3_SET_TIME:
+				{
+					m.loadTime()
+				}
+			case HUC3_STATUS:
+				{
+					m.response = 1
+				}
+			case HUC3_PLAY_TONE:
+				{
+					for _, listener := range m.tones {
+						listener(m.rtc_memory[HUC3_TONE_ADDRESS])
+					}
+				}
+			}
+		}
+	}
+}
+
+func (m *huc3) highBank() int {
+	return int(m.rom_bank) % m.rom_banks
+}
+
+func (m *huc3) ramOffset(address uint16) int {
+	return (int(m.ram_bank)*RAM_BANK_SIZE + int(address-memory.EXTERNAL_RAM_START)) % len(m.ram)
+}
+
+func (m *huc3) Read(address uint16) uint8 {
+	switch {
+	case address < 0x4000:
+		{
+			return readROM(m.rom, int(address))
+		}
+	case address < memory.VRAM_START:
+		{
+			return readROM(m.rom, m.highBank()*ROM_BANK_SIZE+int(address-0x4000))
+		}
+	}
+	switch m.mode {
+	case HUC3_RAM_READ, HUC3_RAM:
+		{
+			if len(m.ram) == 0 {
+				return memory.OPEN_BUS
+			}
+			return m.ram[m.ramOffset(address)]
+		}
+	case HUC3_RTC_RESPONSE:
+		{
+			return 0x80 | m.command<<4 | m.response
+		}
+	case HUC3_RTC_READY:
+		{
+			// the commands finish right away so the clock is always ready
+			return 0x01
+		}
+	case HUC3_IR:
+		{
+			return m.infrared.read()
+		}
+	}
+	return memory.OPEN_BUS
+}
+
+func (m *huc3) Write(address uint16, value uint8) {
+	switch {
+	case address < 0x2000:
+		{
+			m.mode = value & 0x0F
+		}
+	case address < 0x4000:
+		{
+			m.rom_bank = value & 0x7F
+		}
+	case address < 0x6000:
+		{
+			m.ram_bank = value & 0b11
+		}
+	case address < memory.VRAM_START:
+		{
+			// nothing is wired to 0x6000-0x7FFF
+		}
+	default:
+		{
+			switch m.mode {
+			case HUC3_RAM:
+				{
+					if len(m.ram) != 0 {
+						m.ram[m.ramOffset(address)] = value
+					}
+				}
+			case HUC3_RTC_COMMAND:
+				{
+					m.runCommand(value)
+				}
+			case HUC3_IR:
+				{
+					m.infrared.write(value)
+				}
+			}
+		}
+	}
+}
+
+// the rom bank mapped at the address, or the ram bank for 0xA000-0xBFFF
+func (m *huc3) Bank(address uint16) uint16 {
+	switch {
+	case address < 0x4000:
+		{
+			return 0
+		}
+	case address < memory.VRAM_START:
+		{
+			return uint16(m.highBank())
+		}
+	}
+	if len(m.ram) == 0 {
+		return 0
+	}
+	return uint16(m.ramOffset(address) / RAM_BANK_SIZE)
+}
+
+// the ram followed by the time, like the MBC3 footer but with the
+// minutes and days the HuC3 counts, and then the rest of the rtc memory so
+// the alarm survives too
+func (m *huc3) Save() []uint8 {
+	minutes, days := m.time()
+	data := saveRAM(m.ram)
+	data = binary.LittleEndian.AppendUint32(data, uint32(minutes))
+	data = binary.LittleEndian.AppendUint32(data, uint32(days))
+	data = binary.LittleEndian.AppendUint64(data, uint64(m.clock.Now().Unix()))
+	for i := 0; i < len(m.rtc_memory); i += 2 {
+		data = append(data, m.rtc_memory[i]&0x0F|m.rtc_memory[i+1]<<4)
+	}
+	return data
+}
+
+func (m *huc3) Memory() []uint8 {
+	return m.ram
+}
+
+func (m *huc3) Load(data []uint8) error {
+	if len(data) != len(m.ram)+HUC3_FOOTER_SIZE {
+		return loadRAM(m.ram, data)
+	}
+	copy(m.ram, data)
+	footer := data[len(m.ram):]
+	minutes := binary.LittleEndian.Uint32(footer[0:])
+	days := binary.LittleEndian.Uint32(footer[4:])
+	saved := time.Unix(int64(binary.LittleEndian.Uint64(footer[8:])), 0)
+	elapsed := time.Duration(days)*24*time.Hour + time.Duration(minutes)*time.Minute
+	m.base = saved.Add(-elapsed)
+	for i, nibbles := range footer[16:] {
+		m.rtc_memory[2*i] = nibbles & 0x0F
+		m.rtc_memory[2*i+1] = nibbles >> 4
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package cartridge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+func newTestHuC3(clock *testClock) *huc3 {
+	mbc := newHuC3(newBankedROM(128, 0xFE, 0x03))
+	mbc.SetClock(clock)
+	return mbc
+}
+
+func huc3Command(mbc *huc3, command, argument uint8) uint8 {
+	mbc.Write(0x0000, HUC3_RTC_COMMAND)
+	mbc.Write(0xA000, command<<4|argument)
+	mbc.Write(0x0000, HUC3_RTC_RESPONSE)
+	return mbc.Read(0xA000)
+}
+
+// latches the time and reads the 6 nibbles back
+func readHuC3Time(mbc *huc3) (minutes, days uint16) {
+	huc3Command(mbc, HUC3_EXTENDED, HUC3_LATCH_TIME)
+	huc3Command(mbc, HUC3_ADDRESS_LOW, 0)
+	huc3Command(mbc, HUC3_ADDRESS_HIGH, 0)
+	for i := range 6 {
+		nibble := uint16(huc3Command(mbc, HUC3_READ, 0) & 0x0F)
+		if i < 3 {
+			minutes |= nibble << (4 * i)
+		} else {
+			days |= nibble << (4 * (i - 3))
+		}
+	}
+	return minutes, days
+}
+
+func TestHuC3Banks(t *testing.T) {
+	mbc := newTestHuC3(&testClock{now: time.Unix(0, 0)})
+	for bank := range 256 {
+		mbc.Write(0x2000, uint8(bank))
+		if got := int(mbc.Read(0x4000)); got != bank%128 || int(mbc.Bank(0x4000)) != bank%128 {
+			t.Errorf("failed : rom bank 0x%02X expected : %d got : %d", bank, bank%128, got)
+		}
+	}
+
+	type test struct {
+		title    string
+		mode     uint8
+		expected uint8
+	}
+
+	tests := []test{
+		{title: "ram read only", mode: HUC3_RAM_READ, expected: 0x31},
+		{title: "ram", mode: HUC3_RAM, expected: 0x99},
+		{title: "ready", mode: HUC3_RTC_READY, expected: 0x01},
+		{title: "ir", mode: HUC3_IR, expected: HUC_IR_DARK},
+		{title: "nothing", mode: 0x3, expected: memory.OPEN_BUS},
+	}
+
+	mbc.Write(0x4000, 0x01)
+	mbc.Write(0x0000, HUC3_RAM)
+	mbc.Write(0xA000, 0x31)
+	for _, unit_test := range tests {
+		mbc.Write(0x0000, unit_test.mode)
+		mbc.Write(0xA000, 0x99)
+		if value := mbc.Read(0xA000); value != unit_test.expected {
+			t.Errorf("failed : %s expected : 0x%02X got : 0x%02X", unit_test.title, unit_test.expected, value)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+}
+
+func TestHuC3RTC(t *testing.T) {
+	clock := &testClock{now: time.Unix(1_000_000, 0)}
+	mbc := newTestHuC3(clock)
+
+	// 23:59 of day 0x123
+	huc3Command(mbc, HUC3_ADDRESS_LOW, 0)
+	huc3Command(mbc, HUC3_ADDRESS_HIGH, 0)
+	for _, nibble := range []uint8{0xF, 0x9, 0x5, 0x3, 0x2, 0x1} {
+		huc3Command(mbc, HUC3_WRITE, nibble)
+	}
+	if response := huc3Command(mbc, HUC3_EXTENDED, HUC3_SET_TIME); response&0xF0 != 0x80|HUC3_EXTENDED<<4 {
+		t.Errorf("failed : expected the command in the response got : 0x%02X", response)
+	}
+	if minutes, days := readHuC3Time(mbc); minutes != 1439 || days != 0x123 {
+		t.Errorf("failed : expected 1439 minutes of day 0x123 got : %d day 0x%03X", minutes, days)
+	}
+	clock.advance(90 * time.Second)
+	if minutes, days := readHuC3Time(mbc); minutes != 0 || days != 0x124 {
+		t.Errorf("failed : expected the next day got : %d day 0x%03X", minutes, days)
+	}
+	if response := huc3Command(mbc, HUC3_EXTENDED, HUC3_STATUS); response&0x0F != 1 {
+		t.Errorf("failed : expected the status to be 1 got : 0x%02X", response)
+	}
+
+	tones := []uint8{}
+	mbc.OnTone(func(tone uint8) {
+		tones = append(tones, tone)
+	})
+	huc3Command(mbc, HUC3_ADDRESS_LOW, HUC3_TONE_ADDRESS&0x0F)
+	huc3Command(mbc, HUC3_ADDRESS_HIGH, HUC3_TONE_ADDRESS>>4)
+	huc3Command(mbc, HUC3_WRITE, 0x3)
+	huc3Command(mbc, HUC3_EXTENDED, HUC3_PLAY_TONE)
+	if len(tones) != 1 || tones[0] != 0x3 {
+		t.Errorf("failed : expected tone 3 got : %v", tones)
+	}
+
+	// the time keeps going while the game boy is off
+	save := mbc.Save()
+	if len(save) != 0x8000+HUC3_FOOTER_SIZE {
+		t.Fatalf("failed : unexpected save of %d bytes", len(save))
+	}
+	clock.advance(48 * time.Hour)
+	loaded := newTestHuC3(clock)
+	if err := loaded.Load(save); err != nil {
+		t.Fatalf("failed : load %v", err)
+	}
+	if minutes, days := readHuC3Time(loaded); minutes != 0 || days != 0x126 {
+		t.Errorf("failed : expected 2 more days got : %d day 0x%03X", minutes, days)
+	}
+	// and the alarm and the tone are still there
+	huc3Command(loaded, HUC3_ADDRESS_LOW, HUC3_TONE_ADDRESS&0x0F)
+	huc3Command(loaded, HUC3_ADDRESS_HIGH, HUC3_TONE_ADDRESS>>4)
+	if tone := huc3Command(loaded, HUC3_READ, 0) & 0x0F; tone != 0x3 {
+		t.Errorf("failed : expected the tone to be kept got : 0x%X", tone)
+	}
+	if err := loaded.Load(save[:0x8000]); err != nil {
+		t.Errorf("failed : expected a save without the time to load %v", err)
+	}
+}
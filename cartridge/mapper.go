@@ -0,0 +1,132 @@
+package cartridge
+
+import (
+	"fmt"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+// Mapper is the memory bank controller of the cartridge as the bus sees it,
+// it answers for the rom (0x0000-0x7FFF) and the external ram (0xA000-0xBFFF)
+// and writes to the rom area go to its registers
+type Mapper interface {
+	memory.Device
+	memory.Banked
+}
+
+// NewMapper builds the memory bank controller the header asks for, MMM01
+// multicarts are found by the header of their menu at the end of the rom
+func NewMapper(cartridge *Cartridge) (Mapper, error) {
+	if isMMM01(cartridge.ROM) {
+		return newMMM01(cartridge), nil
+	}
+	features, ok := cartridge.Header.Type.Features()
+	if !ok {
+		return nil, fmt.Errorf("cartridge: unknown cartridge type 0x%02X", uint8(cartridge.Header.Type))
+	}
+	switch features.MBC {
+	case NoMBC:
+		{
+			return newROMOnly(cartridge), nil
+		}
+	case MBC1:
+		{
+			return newMBC1(cartridge), nil
+		}
+	case MBC2:
+		{
+			return newMBC2(cartridge), nil
+		}
+	case MBC3:
+		{
+			return newMBC3(cartridge), nil
+		}
+	case MBC5:
+		{
+			return newMBC5(cartridge), nil
+		}
+	case MBC6:
+		{
+			return newMBC6(cartridge), nil
+		}
+	case MBC7:
+		{
+			return newMBC7(cartridge), nil
+		}
+	case MMM01:
+		{
+			return newMMM01(cartridge), nil
+		}
+	case HuC1:
+		{
+			return newHuC1(cartridge), nil
+		}
+	case HuC3:
+		{
+			return newHuC3(cartridge), nil
+		}
+	}
+	return nil, fmt.Errorf("cartridge: %s is not supported", features.MBC)
+}
+
+// Insert plugs the mapper into the rom and external ram regions of the bus
+func Insert(bus *memory.Bus, mapper Mapper) {
+	bus.Map(memory.ROM, mapper)
+	bus.Map(memory.ExternalRAM, mapper)
+}
+
+// reads the byte at offset in the rom, dumps smaller than what the banks
+// point to read as open bus
+func readROM(rom []uint8, offset int) uint8 {
+	if offset >= len(rom) {
+		return memory.OPEN_BUS
+	}
+	return rom[offset]
+}
+
+// the number of rom banks the registers can choose from, the bank numbers
+// wrap around it like the unconnected address lines of the real chip
+func romBanks(rom []uint8) int {
+	banks := 2
+	for banks*ROM_BANK_SIZE < len(rom) {
+		banks *= 2
+	}
+	return banks
+}
+
+// 32kb of rom without any banking, some of them have 8kb of ram too
+type romOnly struct {
+	rom []uint8
+	ram []uint8
+}
+
+func newROMOnly(cartridge *Cartridge) *romOnly {
+	return &romOnly{rom: cartridge.ROM, ram: make([]uint8, cartridge.Header.RAMBytes())}
+}
+
+func (m *romOnly) Read(address uint16) uint8 {
+	if address < memory.VRAM_START {
+		return readROM(m.rom, int(address))
+	}
+	offset := int(address - memory.EXTERNAL_RAM_START)
+	if offset >= len(m.ram) {
+		return memory.OPEN_BUS
+	}
+	return m.ram[offset]
+}
+
+func (m *romOnly) Write(address uint16, value uint8) {
+	if address < memory.VRAM_START {
+		return
+	}
+	if offset := int(address - memory.EXTERNAL_RAM_START); offset < len(m.ram) {
+		m.ram[offset] = value
+	}
+}
+
+func (m *romOnly) Bank(address uint16) uint16 {
+	if address >= 0x4000 && address < memory.VRAM_START {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package cartridge
+
+import (
+	"bytes"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+// MBC1, up to 2MB of rom and 32kb of ram
+// https://gbdev.io/pandocs/MBC1.html
+type mbc1 struct {
+	rom       []uint8
+	ram       []uint8
+	rom_banks int
+
+	// 0x0000-0x1FFF, 0x0A in the lower nibble enables the ram
+	ram_enabled bool
+	// 0x2000-0x3FFF, the lower 5 bits of the rom bank at 0x4000-0x7FFF
+	bank1 uint8
+	// 0x4000-0x5FFF, 2 bits that are either the upper bits of the rom bank or
+	// the ram bank
+	bank2 uint8
+	// 0x6000-0x7FFF, in mode 1 bank2 also applies to 0x0000-0x3FFF and to the
+	// ram
+	mode uint8
+	// MBC1M, the multicarts wire bank2 to bits 4-5 of the rom bank instead of
+	// 5-6 and leave bit 4 of bank1 unconnected
+	multicart bool
+}
+
+func newMBC1(cartridge *Cartridge) *mbc1 {
+	return &mbc1{
+		rom:       cartridge.ROM,
+		ram:       make([]uint8, cartridge.Header.RAMBytes()),
+		rom_banks: romBanks(cartridge.ROM),
+		bank1:     1,
+		multicart: isMultiCart(cartridge.ROM),
+	}
+}
+
+// MBC1M carts are 1MB with four 256kb games in them, each one has its own
+// header so the nintendo logo shows up again at the start of bank 0x10,
+// nothing in the header says it is a multicart
+func isMultiCart(rom []uint8) bool {
+	if len(rom) != 64*ROM_BANK_SIZE {
+		return false
+	}
+	logo := 0x10*ROM_BANK_SIZE + LOGO_ADDRESS
+	return bytes.Equal(rom[logo:logo+len(LOGO)], LOGO[:])
+}
+
+func (m *mbc1) bank2Shift() uint8 {
+	if m.multicart {
+		return 4
+	}
+	return 5
+}
+
+// the bank at 0x0000-0x3FFF is 0 unless mode 1 puts bank2 in it, on big roms
+// that is how banks 0x20, 0x40 and 0x60 are reached
+func (m *mbc1) lowBank() int {
+	if m.mode == 0 {
+		return 0
+	}
+	return int(m.bank2<<m.bank2Shift()) % m.rom_banks
+}
+
+// bank1 can never be 0, writing 0 selects 1, the check only looks at bank1
+// so 0x20, 0x40 and 0x60 turn into 0x21, 0x41 and 0x61
+func (m *mbc1) highBank() int {
+	bank1 := m.bank1
+	if m.multicart {
+		bank1 &= 0x0F
+	}
+	return int(m.bank2<<m.bank2Shift()|bank1) % m.rom_banks
+}
+
+func (m *mbc1) ramOffset(address uint16) int {
+	bank := 0
+	if m.mode == 1 {
+		bank = int(m.bank2)
+	}
+	return (bank*RAM_BANK_SIZE + int(address-memory.EXTERNAL_RAM_START)) % len(m.ram)
+}
+
+func (m *mbc1) Read(address uint16) uint8 {
+	switch {
+	case address < 0x4000:
+		{
+			return readROM(m.rom, m.lowBank()*ROM_BANK_SIZE+int(address))
+		}
+	case address < memory.VRAM_START:
+		{
+			return readROM(m.rom, m.highBank()*ROM_BANK_SIZE+int(address-0x4000))
+		}
+	}
+	if !m.ram_enabled || len(m.ram) == 0 {
+		return memory.OPEN_BUS
+	}
+	return m.ram[m.ramOffset(address)]
+}
+
+func (m *mbc1) Write(address uint16, value uint8) {
+	switch {
+	case address < 0x2000:
+		{
+			m.ram_enabled = value&0x0F == 0x0A
+		}
+	case address < 0x4000:
+		{
+			m.bank1 = value & 0x1F
+			if m.bank1 == 0 {
+				m.bank1 = 1
+			}
+		}
+	case address < 0x6000:
+		{
+			m.bank2 = value & 0b11
+		}
+	case address < memory.VRAM_START:
+		{
+			m.mode = value & 0b1
+		}
+	default:
+		{
+			if m.ram_enabled && len(m.ram) != 0 {
+				m.ram[m.ramOffset(address)] = value
+			}
+		}
+	}
+}
+
+// the rom bank mapped at the address, or the ram bank for 0xA000-0xBFFF
+func (m *mbc1) Bank(address uint16) uint16 {
+	switch {
+	case address < 0x4000:
+		{
+			return uint16(m.lowBank())
+		}
+	case address < memory.VRAM_START:
+		{
+			return uint16(m.highBank())
+		}
+	}
+	if len(m.ram) == 0 {
+		return 0
+	}
+	return uint16(m.ramOffset(address) / RAM_BANK_SIZE)
+}
@@ -0,0 +1,186 @@
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+// a rom where every bank starts with its own number so the test can tell
+// which one is mapped
+func newBankedROM(banks int, cartridge_type Type, ram_size uint8) *Cartridge {
+	rom := make([]uint8, banks*ROM_BANK_SIZE)
+	for bank := range banks {
+		rom[bank*ROM_BANK_SIZE] = uint8(bank)
+		rom[bank*ROM_BANK_SIZE+0x3FFF] = uint8(bank)
+	}
+	rom[TYPE_ADDRESS] = uint8(cartridge_type)
+	rom[RAM_SIZE_ADDRESS] = ram_size
+	cartridge, _ := Parse(rom)
+	return cartridge
+}
+
+func TestMBC1Banks(t *testing.T) {
+	type test struct {
+		title     string
+		banks     int
+		ram_size  uint8
+		multicart bool
+	}
+
+	tests := []test{
+		{title: "256kb rom 8kb ram", banks: 16, ram_size: 0x02},
+		{title: "512kb rom 32kb ram", banks: 32, ram_size: 0x03},
+		{title: "1MB rom", banks: 64, ram_size: 0x02},
+		{title: "2MB rom", banks: 128, ram_size: 0x00},
+		{title: "1MB multicart", banks: 64, multicart: true},
+	}
+
+	for _, unit_test := range tests {
+		cartridge := newBankedROM(unit_test.banks, 0x03, unit_test.ram_size)
+		if unit_test.multicart {
+			for game := range 4 {
+				copy(cartridge.ROM[game*0x10*ROM_BANK_SIZE+LOGO_ADDRESS:], LOGO[:])
+			}
+		}
+		ram_banks := cartridge.Header.RAMBytes() / RAM_BANK_SIZE
+
+		success := true
+		for mode := range 2 {
+			for bank2 := range 8 {
+				for bank1 := range 256 {
+					mbc := newMBC1(cartridge)
+					if mbc.multicart != unit_test.multicart {
+						t.Errorf("failed : %s expected multicart : %t", unit_test.title, unit_test.multicart)
+					}
+					mbc.Write(0x0000, 0x0A)
+					mbc.Write(0x6000, uint8(mode))
+					mbc.Write(0x4000, uint8(bank2))
+					mbc.Write(0x2000, uint8(bank1))
+
+					// what the registers are wired to
+					low_bits, shift := bank1&0x1F, 5
+					if low_bits == 0 {
+						low_bits = 1
+					}
+					if unit_test.multicart {
+						low_bits, shift = low_bits&0x0F, 4
+					}
+					high := ((bank2&0b11)<<shift | low_bits) % unit_test.banks
+					low, ram := 0, 0
+					if mode == 1 {
+						low = ((bank2 & 0b11) << shift) % unit_test.banks
+						ram = bank2 & 0b11
+					}
+
+					if got := int(mbc.Read(0x4000)); got != high%256 || int(mbc.Bank(0x7FFF)) != high {
+						t.Errorf("failed : %s mode %d bank2 0x%02X bank1 0x%02X expected 0x4000 bank : %d got : %d", unit_test.title, mode, bank2, bank1, high, got)
+						success = false
+					}
+					if got := int(mbc.Read(0x3FFF)); got != low || int(mbc.Bank(0x0000)) != low {
+						t.Errorf("failed : %s mode %d bank2 0x%02X bank1 0x%02X expected 0x0000 bank : %d got : %d", unit_test.title, mode, bank2, bank1, low, got)
+						success = false
+					}
+					if ram_banks > 0 && int(mbc.Bank(0xA000)) != ram%ram_banks {
+						t.Errorf("failed : %s mode %d bank2 0x%02X expected ram bank : %d got : %d", unit_test.title, mode, bank2, ram%ram_banks, mbc.Bank(0xA000))
+						success = false
+					}
+					if !success {
+						break
+					}
+				}
+			}
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
+
+func TestMBC1RAM(t *testing.T) {
+	mbc := newMBC1(newBankedROM(16, 0x03, 0x03))
+
+	// disabled ram reads open bus and ignores writes
+	mbc.Write(0xA000, 0x12)
+	if mbc.Read(0xA000) != memory.OPEN_BUS {
+		t.Errorf("failed : disabled ram expected open bus")
+	}
+
+	// anything with 0xA in the lower nibble enables it
+	mbc.Write(0x1FFF, 0xFA)
+	mbc.Write(0x6000, 0x01)
+	for bank := range 4 {
+		mbc.Write(0x4000, uint8(bank))
+		mbc.Write(0xA000, uint8(0x10+bank))
+	}
+	for bank := range 4 {
+		mbc.Write(0x4000, uint8(bank))
+		if value := mbc.Read(0xA000); value != uint8(0x10+bank) {
+			t.Errorf("failed : ram bank %d expected : 0x%02X got : 0x%02X", bank, 0x10+bank, value)
+		}
+	}
+
+	// mode 0 always uses ram bank 0
+	mbc.Write(0x6000, 0x00)
+	if value := mbc.Read(0xA000); value != 0x10 {
+		t.Errorf("failed : mode 0 expected ram bank 0 got : 0x%02X", value)
+	}
+
+	mbc.Write(0x0000, 0x00)
+	if mbc.Read(0xA000) != memory.OPEN_BUS {
+		t.Errorf("failed : expected the ram to be disabled again")
+	}
+
+	// 8kb of ram has a single bank no matter what bank2 says
+	mbc = newMBC1(newBankedROM(16, 0x03, 0x02))
+	mbc.Write(0x0000, 0x0A)
+	mbc.Write(0xA123, 0x42)
+	mbc.Write(0x6000, 0x01)
+	mbc.Write(0x4000, 0x03)
+	if value := mbc.Read(0xA123); value != 0x42 {
+		t.Errorf("failed : 8kb ram expected to ignore bank2 got : 0x%02X", value)
+	}
+}
+
+func TestNewMapper(t *testing.T) {
+	type test struct {
+		cartridge_type Type
+		ok             bool
+	}
+
+	tests := []test{
+		{cartridge_type: 0x00, ok: true},
+		{cartridge_type: 0x09, ok: true},
+		{cartridge_type: 0x01, ok: true},
+		{cartridge_type: 0x03, ok: true},
+		{cartridge_type: 0x10, ok: true},
+		{cartridge_type: 0x11, ok: true},
+		{cartridge_type: 0x1E, ok: true},
+		{cartridge_type: 0x06, ok: true},
+		{cartridge_type: 0x0B, ok: true},
+		{cartridge_type: 0x20, ok: true},
+		{cartridge_type: 0x22, ok: true},
+		{cartridge_type: 0xFE, ok: true},
+		{cartridge_type: 0xFF, ok: true},
+		{cartridge_type: 0x04, ok: false},
+		{cartridge_type: 0xFC, ok: false},
+		{cartridge_type: 0xFD, ok: false},
+	}
+
+	for _, unit_test := range tests {
+		_, err := NewMapper(newBankedROM(2, unit_test.cartridge_type, 0x00))
+		if (err == nil) != unit_test.ok {
+			t.Errorf("failed : type 0x%02X expected ok : %t got : %v", uint8(unit_test.cartridge_type), unit_test.ok, err)
+		}
+	}
+
+	bus := memory.NewBus()
+	cartridge := newBankedROM(2, 0x09, 0x02)
+	mapper, _ := NewMapper(cartridge)
+	Insert(bus, mapper)
+	bus.Write(0xA010, 0x34)
+	bus.Write(0x4000, 0x34)
+	if bus.Read(0xA010) != 0x34 || bus.Read(0x4000) != 0x01 || bus.Bank(0x4000) != 1 {
+		t.Errorf("failed : rom only expected ram at 0xA000 and rom bank 1 at 0x4000")
+	}
+}
@@ -0,0 +1,108 @@
+package cartridge
+
+import "github.com/chilepikmin/gamegorl/memory"
+
+// the ram is inside the MBC2 itself, 512 cells of 4 bits
+const MBC2_RAM_SIZE = 512
+
+// MBC2, up to 256kb of rom and its own 512x4 bits of ram, both registers
+// live in 0x0000-0x3FFF and bit 8 of the address says which one is written
+// https://gbdev.io/pandocs/MBC2.html
+type mbc2 struct {
+	rom       []uint8
+	ram       [MBC2_RAM_SIZE]uint8
+	rom_banks int
+
+	// bit 8 clear, 0x0A in the lower nibble enables the ram
+	ram_enabled bool
+	// bit 8 set, 4 bits and 0 selects 1 like MBC1
+	rom_bank uint8
+}
+
+func newMBC2(cartridge *Cartridge) *mbc2 {
+	return &mbc2{
+		rom:       cartridge.ROM,
+		rom_banks: romBanks(cartridge.ROM),
+		rom_bank:  1,
+	}
+}
+
+func (m *mbc2) highBank() int {
+	return int(m.rom_bank) % m.rom_banks
+}
+
+// only 9 address lines reach the ram so it repeats all over 0xA000-0xBFFF
+func ramCell(address uint16) int {
+	return int(address-memory.EXTERNAL_RAM_START) % MBC2_RAM_SIZE
+}
+
+func (m *mbc2) Read(address uint16) uint8 {
+	switch {
+	case address < 0x4000:
+		{
+			return readROM(m.rom, int(address))
+		}
+	case address < memory.VRAM_START:
+		{
+			return readROM(m.rom, m.highBank()*ROM_BANK_SIZE+int(address-0x4000))
+		}
+	}
+	if !m.ram_enabled {
+		return memory.OPEN_BUS
+	}
+	// the upper 4 bits arent connected and read as 1
+	return 0xF0 | m.ram[ramCell(address)]
+}
+
+func (m *mbc2) Write(address uint16, value uint8) {
+	switch {
+	case address < 0x4000:
+		{
+			if address&0x100 == 0 {
+				m.ram_enabled = value&0x0F == 0x0A
+				return
+			}
+			m.rom_bank = value & 0x0F
+			if m.rom_bank == 0 {
+				m.rom_bank = 1
+			}
+		}
+	case address < memory.VRAM_START:
+		{
+			// nothing is wired to 0x4000-0x7FFF
+		}
+	default:
+		{
+			if m.ram_enabled {
+				m.ram[ramCell(address)] = value & 0x0F
+			}
+		}
+	}
+}
+
+// the rom bank mapped at the address, the ram has a single bank
+func (m *mbc2) Bank(address uint16) uint16 {
+	if address >= 0x4000 && address < memory.VRAM_START {
+		return uint16(m.highBank())
+	}
+	return 0
+}
+
+// one byte per cell with the upper nibble clear
+func (m *mbc2) Save() []uint8 {
+	return saveRAM(m.ram[:])
+}
+
+func (m *mbc2) Memory() []uint8 {
+	return m.ram[:]
+}
+
+func (m *mbc2) Load(data []uint8) error {
+	if err := loadRAM(m.ram[:], data); err != nil {
+		return err
+	}
+	for i := range m.ram {
+		m.ram[i] &= 0x0F
+	}
+	return nil
+}
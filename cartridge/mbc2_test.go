@@ -0,0 +1,70 @@
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+func TestMBC2(t *testing.T) {
+	mbc := newMBC2(newBankedROM(16, 0x06, 0x00))
+
+	type test struct {
+		title   string
+		address uint16
+		value   uint8
+		bank    int
+		ram     bool
+	}
+
+	// bit 8 of the address picks the register anywhere in 0x0000-0x3FFF
+	tests := []test{
+		{title: "rom bank at 0x2100", address: 0x2100, value: 0x05, bank: 5},
+		{title: "rom bank at 0x0100", address: 0x0100, value: 0x03, bank: 3},
+		{title: "rom bank 0 is 1", address: 0x3FFF, value: 0x00, bank: 1},
+		{title: "rom bank upper bits ignored", address: 0x0100, value: 0xFE, bank: 14},
+		{title: "ram enable at 0x0000", address: 0x0000, value: 0x0A, bank: 14, ram: true},
+		{title: "ram disable at 0x3EFF", address: 0x3EFF, value: 0x00, bank: 14},
+		{title: "ram enable at 0x2000", address: 0x2000, value: 0xBA, bank: 14, ram: true},
+	}
+
+	for _, unit_test := range tests {
+		mbc.Write(unit_test.address, unit_test.value)
+		mbc.Write(0xA000, 0x05)
+		ram := mbc.Read(0xA000) != memory.OPEN_BUS
+		if bank := int(mbc.Read(0x4000)); bank != unit_test.bank || int(mbc.Bank(0x4000)) != unit_test.bank || ram != unit_test.ram {
+			t.Errorf("failed : %s expected bank : %d ram : %t got bank : %d ram : %t", unit_test.title, unit_test.bank, unit_test.ram, bank, ram)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+
+	// 4 bit cells echoed every 512 bytes
+	mbc.Write(0xA1FF, 0x3C)
+	for address := 0xA1FF; address <= 0xBFFF; address += MBC2_RAM_SIZE {
+		if value := mbc.Read(uint16(address)); value != 0xFC {
+			t.Errorf("failed : 0x%04X expected : 0xFC got : 0x%02X", address, value)
+		}
+	}
+	mbc.Write(0xBE00, 0x07)
+	if value := mbc.Read(0xA000); value != 0xF7 {
+		t.Errorf("failed : expected the echo write at 0xA000 got : 0x%02X", value)
+	}
+
+	save := mbc.Save()
+	if len(save) != MBC2_RAM_SIZE || save[0] != 0x07 || save[0x1FF] != 0x0C {
+		t.Errorf("failed : unexpected save of %d bytes", len(save))
+	}
+	loaded := newMBC2(newBankedROM(16, 0x06, 0x00))
+	save[0x10] = 0xAB
+	if err := loaded.Load(save); err != nil {
+		t.Fatalf("failed : load %v", err)
+	}
+	loaded.Write(0x0000, 0x0A)
+	if loaded.Read(0xA000) != 0xF7 || loaded.Read(0xA010) != 0xFB || loaded.Read(0xA1FF) != 0xFC {
+		t.Errorf("failed : expected the cells back")
+	}
+	if err := loaded.Load(save[:0x100]); err == nil {
+		t.Errorf("failed : expected a short save to fail")
+	}
+}
@@ -0,0 +1,165 @@
+package cartridge
+
+import "github.com/chilepikmin/gamegorl/memory"
+
+// MBC3, up to 2MB of rom, 32kb of ram and a real time clock on the
+// cartridges with a timer, the MBC30 of the japanese pokemon crystal is the
+// same chip with one more bit for the rom and ram banks so the bank numbers
+// are only wrapped around the size of the cartridge
+// https://gbdev.io/pandocs/MBC3.html
+type mbc3 struct {
+	rom       []uint8
+	ram       []uint8
+	rom_banks int
+	rtc       *rtc
+
+	// 0x0000-0x1FFF, 0x0A enables the ram and the clock registers
+	ram_enabled bool
+	// 0x2000-0x3FFF, the rom bank at 0x4000-0x7FFF
+	rom_bank uint8
+	// 0x4000-0x5FFF, 0x00-0x07 select a ram bank and 0x08-0x0C a clock
+	// register
+	ram_bank uint8
+}
+
+func newMBC3(cartridge *Cartridge) *mbc3 {
+	mbc := &mbc3{
+		rom:       cartridge.ROM,
+		ram:       make([]uint8, cartridge.Header.RAMBytes()),
+		rom_banks: romBanks(cartridge.ROM),
+		rom_bank:  1,
+	}
+	if features, _ := cartridge.Header.Type.Features(); features.Timer {
+		mbc.rtc = newRTC(systemClock{})
+	}
+	return mbc
+}
+
+func (m *mbc3) SetClock(clock Clock) {
+	if m.rtc != nil {
+		m.rtc.setClock(clock)
+	}
+}
+
+func (m *mbc3) selectsRTC() bool {
+	return m.ram_bank >= RTC_SECONDS && m.ram_bank <= RTC_DAY_HIGH
+}
+
+func (m *mbc3) ramOffset(address uint16) int {
+	return (int(m.ram_bank)*RAM_BANK_SIZE + int(address-memory.EXTERNAL_RAM_START)) % len(m.ram)
+}
+
+func (m *mbc3) highBank() int {
+	return int(m.rom_bank) % m.rom_banks
+}
+
+func (m *mbc3) Read(address uint16) uint8 {
+	switch {
+	case address < 0x4000:
+		{
+			return readROM(m.rom, int(address))
+		}
+	case address < memory.VRAM_START:
+		{
+			return readROM(m.rom, m.highBank()*ROM_BANK_SIZE+int(address-0x4000))
+		}
+	}
+	if !m.ram_enabled {
+		return memory.OPEN_BUS
+	}
+	if m.selectsRTC() {
+		if m.rtc == nil {
+			return memory.OPEN_BUS
+		}
+		return m.rtc.read(m.ram_bank)
+	}
+	if m.ram_bank > 0x07 || len(m.ram) == 0 {
+		return memory.OPEN_BUS
+	}
+	return m.ram[m.ramOffset(address)]
+}
+
+func (m *mbc3) Write(address uint16, value uint8) {
+	switch {
+	case address < 0x2000:
+		{
+			m.ram_enabled = value&0x0F == 0x0A
+		}
+	case address < 0x4000:
+		{
+			m.rom_bank = value
+			if m.rom_bank == 0 {
+				m.rom_bank = 1
+			}
+		}
+	case address < 0x6000:
+		{
+			m.ram_bank = value & 0x0F
+		}
+	case address < memory.VRAM_START:
+		{
+			if m.rtc != nil {
+				m.rtc.latch(value)
+			}
+		}
+	default:
+		{
+			if !m.ram_enabled {
+				return
+			}
+			if m.selectsRTC() {
+				if m.rtc != nil {
+					m.rtc.write(m.ram_bank, value)
+				}
+				return
+			}
+			if m.ram_bank <= 0x07 && len(m.ram) != 0 {
+				m.ram[m.ramOffset(address)] = value
+			}
+		}
+	}
+}
+
+// the rom bank mapped at the address, or the ram bank for 0xA000-0xBFFF
+func (m *mbc3) Bank(address uint16) uint16 {
+	switch {
+	case address < 0x4000:
+		{
+			return 0
+		}
+	case address < memory.VRAM_START:
+		{
+			return uint16(m.highBank())
+		}
+	}
+	if len(m.ram) == 0 || m.ram_bank > 0x07 {
+		return 0
+	}
+	return uint16(m.ramOffset(address) / RAM_BANK_SIZE)
+}
+
+// the ram followed by the clock footer when the cartridge has one
+func (m *mbc3) Save() []uint8 {
+	data := saveRAM(m.ram)
+	if m.rtc != nil {
+		data = append(data, m.rtc.save()...)
+	}
+	return data
+}
+
+func (m *mbc3) Memory() []uint8 {
+	return m.ram
+}
+
+// saves without the footer are accepted too, the clock keeps its time then
+func (m *mbc3) Load(data []uint8) error {
+	footer := len(data) - len(m.ram)
+	if m.rtc == nil || (footer != RTC_FOOTER_SIZE && footer != RTC_FOOTER_SIZE_32) {
+		return loadRAM(m.ram, data)
+	}
+	if err := loadRAM(m.ram, data[:len(m.ram)]); err != nil {
+		return err
+	}
+	m.rtc.load(data[len(m.ram):])
+	return nil
+}
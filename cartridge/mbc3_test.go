@@ -0,0 +1,214 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+// a clock the tests move forward by hand
+type testClock struct {
+	now time.Time
+}
+
+func (clock *testClock) Now() time.Time {
+	return clock.now
+}
+
+func (clock *testClock) advance(duration time.Duration) {
+	clock.now = clock.now.Add(duration)
+}
+
+func newTestMBC3(clock *testClock) *mbc3 {
+	mbc := newMBC3(newBankedROM(128, 0x10, 0x03))
+	mbc.SetClock(clock)
+	mbc.Write(0x0000, 0x0A)
+	return mbc
+}
+
+// latches the clock and reads the 5 registers
+func readRTC(mbc *mbc3) [5]uint8 {
+	mbc.Write(0x6000, 0x00)
+	mbc.Write(0x6000, 0x01)
+	registers := [5]uint8{}
+	for i := range registers {
+		mbc.Write(0x4000, uint8(RTC_SECONDS+i))
+		registers[i] = mbc.Read(0xA000)
+	}
+	return registers
+}
+
+func writeRTC(mbc *mbc3, registers [5]uint8) {
+	for i, value := range registers {
+		mbc.Write(0x4000, uint8(RTC_SECONDS+i))
+		mbc.Write(0xA000, value)
+	}
+}
+
+func TestMBC3Banks(t *testing.T) {
+	mbc := newMBC3(newBankedROM(128, 0x13, 0x03))
+	for bank := range 256 {
+		mbc.Write(0x2000, uint8(bank))
+		expected := bank % 128
+		if bank == 0 {
+			expected = 1
+		}
+		if got := int(mbc.Read(0x4000)); got != expected || int(mbc.Bank(0x4000)) != expected || mbc.Read(0x0000) != 0 {
+			t.Errorf("failed : rom bank 0x%02X expected : %d got : %d", bank, expected, got)
+		}
+	}
+
+	mbc.Write(0x0000, 0x0A)
+	for bank := range 4 {
+		mbc.Write(0x4000, uint8(bank))
+		mbc.Write(0xBFFF, uint8(0x20+bank))
+	}
+	for bank := range 4 {
+		mbc.Write(0x4000, uint8(bank))
+		if value := mbc.Read(0xBFFF); value != uint8(0x20+bank) || mbc.Bank(0xA000) != uint16(bank) {
+			t.Errorf("failed : ram bank %d expected : 0x%02X got : 0x%02X", bank, 0x20+bank, value)
+		}
+	}
+
+	// no clock on this one
+	mbc.Write(0x4000, RTC_SECONDS)
+	if mbc.Read(0xA000) != memory.OPEN_BUS {
+		t.Errorf("failed : expected open bus for the clock of a cartridge without one")
+	}
+	mbc.Write(0x0000, 0x00)
+	mbc.Write(0x4000, 0x00)
+	if mbc.Read(0xA000) != memory.OPEN_BUS {
+		t.Errorf("failed : disabled ram expected open bus")
+	}
+}
+
+func TestMBC3RTC(t *testing.T) {
+	type test struct {
+		title    string
+		start    [5]uint8
+		elapsed  time.Duration
+		expected [5]uint8
+	}
+
+	tests := []test{
+		{title: "a second", elapsed: time.Second, expected: [5]uint8{1, 0, 0, 0, 0}},
+		{title: "less than a second", elapsed: 999 * time.Millisecond, expected: [5]uint8{0, 0, 0, 0, 0}},
+		{title: "a day and a bit", elapsed: 25*time.Hour + 61*time.Second, expected: [5]uint8{1, 1, 1, 1, 0}},
+		{title: "day 256", start: [5]uint8{59, 59, 23, 0xFF, 0}, elapsed: time.Second, expected: [5]uint8{0, 0, 0, 0, 1}},
+		{title: "day counter overflow", start: [5]uint8{59, 59, 23, 0xFF, 1}, elapsed: time.Second, expected: [5]uint8{0, 0, 0, 0, RTC_CARRY}},
+		{title: "carry stays set", start: [5]uint8{0, 0, 0, 0, RTC_CARRY}, elapsed: time.Hour, expected: [5]uint8{0, 0, 1, 0, RTC_CARRY}},
+		{title: "halted", start: [5]uint8{5, 0, 0, 0, RTC_HALT}, elapsed: time.Hour, expected: [5]uint8{5, 0, 0, 0, RTC_HALT}},
+		{title: "unused bits", start: [5]uint8{0xFF, 0xFF, 0xFF, 0x00, 0x3E | RTC_HALT}, expected: [5]uint8{0x3F, 0x3F, 0x1F, 0, RTC_HALT}},
+		{title: "invalid seconds wrap without carry", start: [5]uint8{63, 0, 0, 0, 0}, elapsed: 2 * time.Second, expected: [5]uint8{1, 0, 0, 0, 0}},
+		{title: "invalid hours", start: [5]uint8{59, 59, 31, 0, 0}, elapsed: time.Second, expected: [5]uint8{0, 0, 0, 0, 0}},
+		{title: "invalid minutes then counting", start: [5]uint8{59, 63, 0, 0, 0}, elapsed: 61 * time.Second, expected: [5]uint8{0, 1, 0, 0, 0}},
+	}
+
+	for _, unit_test := range tests {
+		clock := &testClock{now: time.Unix(1_000_000, 0)}
+		mbc := newTestMBC3(clock)
+		writeRTC(mbc, unit_test.start)
+		clock.advance(unit_test.elapsed)
+		if got := readRTC(mbc); got != unit_test.expected {
+			t.Errorf("failed : %s expected : %v got : %v", unit_test.title, unit_test.expected, got)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+}
+
+func TestMBC3Latch(t *testing.T) {
+	clock := &testClock{now: time.Unix(1_000_000, 0)}
+	mbc := newTestMBC3(clock)
+	readRTC(mbc)
+
+	// the latched value holds until 0 and 1 are written again
+	clock.advance(10 * time.Second)
+	mbc.Write(0x4000, RTC_SECONDS)
+	if value := mbc.Read(0xA000); value != 0 {
+		t.Errorf("failed : expected the latched seconds to stay at 0 got : %d", value)
+	}
+	mbc.Write(0x6000, 0x01)
+	if value := mbc.Read(0xA000); value != 0 {
+		t.Errorf("failed : writing 1 alone expected not to latch got : %d", value)
+	}
+	mbc.Write(0x6000, 0x00)
+	mbc.Write(0x6000, 0x01)
+	if value := mbc.Read(0xA000); value != 10 {
+		t.Errorf("failed : expected the latched seconds to be 10 got : %d", value)
+	}
+
+	// writing the seconds restarts the second that was going on
+	clock.advance(500 * time.Millisecond)
+	mbc.Write(0xA000, 0)
+	clock.advance(700 * time.Millisecond)
+	if got := readRTC(mbc); got[0] != 0 {
+		t.Errorf("failed : expected the second to restart got : %d", got[0])
+	}
+}
+
+func TestMBC3Save(t *testing.T) {
+	clock := &testClock{now: time.Unix(1_000_000, 0)}
+	mbc := newTestMBC3(clock)
+	mbc.Write(0x4000, 0x03)
+	mbc.Write(0xA000, 0x99)
+	writeRTC(mbc, [5]uint8{10, 20, 3, 0xFF, 1})
+	readRTC(mbc)
+
+	save := mbc.Save()
+	if len(save) != 0x8000+RTC_FOOTER_SIZE {
+		t.Fatalf("failed : expected a save of %d bytes got : %d", 0x8000+RTC_FOOTER_SIZE, len(save))
+	}
+	footer := save[0x8000:]
+	if binary.LittleEndian.Uint32(footer[0:]) != 10 || binary.LittleEndian.Uint32(footer[16:]) != 1 || binary.LittleEndian.Uint32(footer[24:]) != 20 {
+		t.Errorf("failed : unexpected footer % X", footer)
+	}
+	if binary.LittleEndian.Uint64(footer[40:]) != 1_000_000 {
+		t.Errorf("failed : expected the save time in the footer got : %d", binary.LittleEndian.Uint64(footer[40:]))
+	}
+
+	type test struct {
+		title    string
+		save     []uint8
+		expected [5]uint8
+		err      bool
+	}
+
+	// 44 byte footers only keep the lower 4 bytes of the time
+	short := append(bytes.Clone(save[:0x8000+40]), save[0x8000+40:0x8000+44]...)
+
+	tests := []test{
+		{title: "48 byte footer", save: save, expected: [5]uint8{10, 20, 4, 0xFF, 1}},
+		{title: "44 byte footer", save: short, expected: [5]uint8{10, 20, 4, 0xFF, 1}},
+		{title: "ram only keeps the clock running", save: save[:0x8000], expected: [5]uint8{0, 0, 1, 0, 0}},
+		{title: "wrong size", save: save[:0x100], err: true},
+	}
+
+	for _, unit_test := range tests {
+		clock := &testClock{now: time.Unix(1_000_000, 0)}
+		loaded := newTestMBC3(clock)
+		// an hour went by while the game boy was off
+		clock.advance(time.Hour)
+		err := loaded.Load(unit_test.save)
+		if (err != nil) != unit_test.err {
+			t.Errorf("failed : %s expected error : %t got : %v", unit_test.title, unit_test.err, err)
+			continue
+		}
+		if unit_test.err {
+			t.Logf("ok: %s", unit_test.title)
+			continue
+		}
+		loaded.Write(0x4000, 0x03)
+		if value := loaded.Read(0xA000); value != 0x99 {
+			t.Errorf("failed : %s expected the ram back got : 0x%02X", unit_test.title, value)
+		}
+		if got := readRTC(loaded); got != unit_test.expected {
+			t.Errorf("failed : %s expected : %v got : %v", unit_test.title, unit_test.expected, got)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+}
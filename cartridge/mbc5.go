@@ -0,0 +1,148 @@
+package cartridge
+
+import "github.com/chilepikmin/gamegorl/memory"
+
+// Rumble is implemented by the mappers that can drive a rumble motor, the
+// listeners are called every time the game turns it on or off
+type Rumble interface {
+	OnRumble(listener func(on bool))
+}
+
+// on the rumble carts bit 3 of the ram bank turns the motor on
+const MBC5_RUMBLE_BIT = 1 << 3
+
+// MBC5, up to 8MB of rom with a 9 bit bank number, 128kb of ram and on some
+// carts a rumble motor, unlike MBC1 and MBC3 rom bank 0 can be mapped at
+// 0x4000-0x7FFF
+// https://gbdev.io/pandocs/MBC5.html
+type mbc5 struct {
+	rom       []uint8
+	ram       []uint8
+	rom_banks int
+
+	// 0x0000-0x1FFF, only 0x0A enables the ram
+	ram_enabled bool
+	// 0x2000-0x2FFF are the lower 8 bits and 0x3000-0x3FFF bit 8
+	rom_bank uint16
+	// 0x4000-0x5FFF, 4 bits or 3 on the rumble carts
+	ram_bank uint8
+
+	rumble    bool
+	rumbling  bool
+	listeners []func(on bool)
+}
+
+func newMBC5(cartridge *Cartridge) *mbc5 {
+	features, _ := cartridge.Header.Type.Features()
+	return &mbc5{
+		rom:       cartridge.ROM,
+		ram:       make([]uint8, cartridge.Header.RAMBytes()),
+		rom_banks: romBanks(cartridge.ROM),
+		rom_bank:  1,
+		rumble:    features.Rumble,
+	}
+}
+
+func (m *mbc5) OnRumble(listener func(on bool)) {
+	m.listeners = append(m.listeners, listener)
+}
+
+func (m *mbc5) setRumble(on bool) {
+	if on == m.rumbling {
+		return
+	}
+	m.rumbling = on
+	for _, listener := range m.listeners {
+		listener(on)
+	}
+}
+
+func (m *mbc5) ramOffset(address uint16) int {
+	return (int(m.ram_bank)*RAM_BANK_SIZE + int(address-memory.EXTERNAL_RAM_START)) % len(m.ram)
+}
+
+func (m *mbc5) highBank() int {
+	return int(m.rom_bank) % m.rom_banks
+}
+
+func (m *mbc5) Read(address uint16) uint8 {
+	switch {
+	case address < 0x4000:
+		{
+			return readROM(m.rom, int(address))
+		}
+	case address < memory.VRAM_START:
+		{
+			return readROM(m.rom, m.highBank()*ROM_BANK_SIZE+int(address-0x4000))
+		}
+	}
+	if !m.ram_enabled || len(m.ram) == 0 {
+		return memory.OPEN_BUS
+	}
+	return m.ram[m.ramOffset(address)]
+}
+
+func (m *mbc5) Write(address uint16, value uint8) {
+	switch {
+	case address < 0x2000:
+		{
+			m.ram_enabled = value == 0x0A
+		}
+	case address < 0x3000:
+		{
+			m.rom_bank = m.rom_bank&0x100 | uint16(value)
+		}
+	case address < 0x4000:
+		{
+			m.rom_bank = m.rom_bank&0xFF | uint16(value&0b1)<<8
+		}
+	case address < 0x6000:
+		{
+			m.ram_bank = value & 0x0F
+			if m.rumble {
+				m.setRumble(value&MBC5_RUMBLE_BIT != 0)
+				m.ram_bank &^= MBC5_RUMBLE_BIT
+			}
+		}
+	case address < memory.VRAM_START:
+		{
+			// nothing is wired to 0x6000-0x7FFF
+		}
+	default:
+		{
+			if m.ram_enabled && len(m.ram) != 0 {
+				m.ram[m.ramOffset(address)] = value
+			}
+		}
+	}
+}
+
+// the rom bank mapped at the address, or the ram bank for 0xA000-0xBFFF
+func (m *mbc5) Bank(address uint16) uint16 {
+	switch {
+	case address < 0x4000:
+		{
+			return 0
+		}
+	case address < memory.VRAM_START:
+		{
+			return uint16(m.highBank())
+		}
+	}
+	if len(m.ram) == 0 {
+		return 0
+	}
+	return uint16(m.ramOffset(address) / RAM_BANK_SIZE)
+}
+
+func (m *mbc5) Save() []uint8 {
+	return saveRAM(m.ram)
+}
+
+func (m *mbc5) Memory() []uint8 {
+	return m.ram
+}
+
+func (m *mbc5) Load(data []uint8) error {
+	return loadRAM(m.ram, data)
+}
@@ -0,0 +1,97 @@
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+func TestMBC5Banks(t *testing.T) {
+	type test struct {
+		title string
+		banks int
+	}
+
+	tests := []test{
+		{title: "8MB rom", banks: 512},
+		{title: "1MB rom", banks: 64},
+	}
+
+	for _, unit_test := range tests {
+		cartridge := newBankedROM(unit_test.banks, 0x1B, 0x05)
+		// the banks above 255 keep bit 8 at the end of the bank
+		for bank := range unit_test.banks {
+			cartridge.ROM[bank*ROM_BANK_SIZE+1] = uint8(bank >> 8)
+		}
+		mbc := newMBC5(cartridge)
+
+		success := true
+		for bank := range 512 {
+			mbc.Write(0x2000, uint8(bank))
+			mbc.Write(0x3000, uint8(bank>>8))
+			expected := bank % unit_test.banks
+			got := int(mbc.Read(0x4000)) | int(mbc.Read(0x4001))<<8
+			if got != expected || int(mbc.Bank(0x4000)) != expected {
+				t.Errorf("failed : %s rom bank 0x%03X expected : %d got : %d", unit_test.title, bank, expected, got)
+				success = false
+				break
+			}
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+
+	mbc := newMBC5(newBankedROM(4, 0x1B, 0x04))
+	// only 0x0A enables the ram
+	mbc.Write(0x0000, 0xFA)
+	mbc.Write(0xA000, 0x12)
+	if mbc.Read(0xA000) != memory.OPEN_BUS {
+		t.Errorf("failed : 0xFA expected to leave the ram disabled")
+	}
+	mbc.Write(0x0000, 0x0A)
+	for bank := range 16 {
+		mbc.Write(0x4000, uint8(bank))
+		mbc.Write(0xA000, uint8(0x30+bank))
+	}
+	for bank := range 16 {
+		mbc.Write(0x4000, uint8(bank))
+		if value := mbc.Read(0xA000); value != uint8(0x30+bank) || mbc.Bank(0xA000) != uint16(bank) {
+			t.Errorf("failed : ram bank %d expected : 0x%02X got : 0x%02X", bank, 0x30+bank, value)
+		}
+	}
+}
+
+func TestMBC5Rumble(t *testing.T) {
+	mbc := newMBC5(newBankedROM(4, 0x1E, 0x03))
+	events := []bool{}
+	mbc.OnRumble(func(on bool) {
+		events = append(events, on)
+	})
+
+	mbc.Write(0x0000, 0x0A)
+	mbc.Write(0x4000, 0x01)
+	mbc.Write(0xA000, 0x11)
+	// the motor bit doesnt change the ram bank
+	mbc.Write(0x4000, 0x09)
+	if value := mbc.Read(0xA000); value != 0x11 {
+		t.Errorf("failed : expected the rumble bit to keep ram bank 1 got : 0x%02X", value)
+	}
+	// the listeners only hear about changes
+	mbc.Write(0x4000, 0x08)
+	mbc.Write(0x4000, 0x00)
+	if len(events) != 2 || !events[0] || events[1] {
+		t.Errorf("failed : expected rumble on and off got : %v", events)
+	}
+
+	// without a motor bit 3 is part of the ram bank
+	mbc = newMBC5(newBankedROM(4, 0x1B, 0x04))
+	mbc.OnRumble(func(on bool) {
+		t.Errorf("failed : rumble event on a cartridge without rumble")
+	})
+	mbc.Write(0x0000, 0x0A)
+	mbc.Write(0x4000, 0x08)
+	if mbc.Bank(0xA000) != 8 {
+		t.Errorf("failed : expected ram bank 8 got : %d", mbc.Bank(0xA000))
+	}
+}
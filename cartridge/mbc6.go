@@ -0,0 +1,287 @@
+package cartridge
+
+import "github.com/chilepikmin/gamegorl/memory"
+
+const (
+	// MBC6 banks are half the usual size, 0x4000-0x7FFF and 0xA000-0xBFFF
+	// are split in two windows that are banked on their own
+	MBC6_ROM_BANK_SIZE = 0x2000
+	MBC6_RAM_BANK_SIZE = 0x1000
+	// the macronix flash next to the rom, erased in sectors of 128kb
+	MBC6_FLASH_SIZE        = 0x100000
+	MBC6_FLASH_SECTOR_SIZE = 0x20000
+	// a window with 0x08 in its select register shows the flash
+	MBC6_SELECT_FLASH = 0x08
+)
+
+// the flash commands are written to 0x5555 and 0x2AAA of the flash, with
+// bank 2 in window A that is 0x5555 and with bank 1 0x4AAA
+const (
+	FLASH_UNLOCK_1_ADDRESS = 0x5555
+	FLASH_UNLOCK_2_ADDRESS = 0x2AAA
+
+	FLASH_UNLOCK_1     = 0xAA
+	FLASH_UNLOCK_2     = 0x55
+	FLASH_PROGRAM      = 0xA0
+	FLASH_ERASE        = 0x80
+	FLASH_ERASE_SECTOR = 0x30
+	FLASH_ERASE_CHIP   = 0x10
+	FLASH_RESET        = 0xF0
+)
+
+type flashState uint8
+
+const (
+	flashIdle flashState = iota
+	flashUnlocked1
+	flashUnlocked2
+	flashProgram
+	flashErase
+	flashEraseUnlocked1
+	flashEraseUnlocked2
+)
+
+// MBC6, only used by Net de Get, 1MB of rom, 1MB of flash and 32kb of ram
+// https://gbdev.io/pandocs/MBC6.html
+type mbc6 struct {
+	rom []uint8
+	// the ram followed by the flash, ram and flash are the two halves of it
+	save  []uint8
+	ram   []uint8
+	flash []uint8
+
+	// 0x0000-0x03FF
+	ram_enabled bool
+	// 0x0400-0x07FF and 0x0800-0x0BFF, the ram banks of 0xA000 and 0xB000
+	ram_banks [2]uint8
+	// 0x0C00-0x0FFF, the flash can only be seen with bit 0 set
+	flash_enabled bool
+	// 0x1000, bit 0 lets the commands program and erase the flash
+	flash_writable bool
+	// 0x2000-0x27FF and 0x3000-0x37FF, the banks of 0x4000 and 0x6000
+	rom_banks [2]uint8
+	// 0x2800-0x2FFF and 0x3800-0x3FFF, rom or flash in each window
+	selects [2]uint8
+
+	flash_state flashState
+}
+
+func newMBC6(cartridge *Cartridge) *mbc6 {
+	ram_size := cartridge.Header.RAMBytes()
+	save := make([]uint8, ram_size+MBC6_FLASH_SIZE)
+	eraseFlash(save[ram_size:])
+	return &mbc6{
+		rom:   cartridge.ROM,
+		save:  save,
+		ram:   save[:ram_size],
+		flash: save[ram_size:],
+	}
+}
+
+// 0 for 0x4000-0x5FFF and 0xA000-0xAFFF, 1 for the other half
+func mbc6Window(address uint16) int {
+	if address >= memory.EXTERNAL_RAM_START {
+		return int(address>>12) & 1
+	}
+	return int(address>>13) & 1
+}
+
+func (m *mbc6) showsFlash(window int) bool {
+	return m.flash_enabled && m.selects[window] == MBC6_SELECT_FLASH
+}
+
+func (m *mbc6) windowOffset(address uint16) int {
+	window := mbc6Window(address)
+	return int(m.rom_banks[window])*MBC6_ROM_BANK_SIZE + int(address&(MBC6_ROM_BANK_SIZE-1))
+}
+
+func (m *mbc6) ramOffset(address uint16) int {
+	window := mbc6Window(address)
+	return (int(m.ram_banks[window])*MBC6_RAM_BANK_SIZE + int(address&(MBC6_RAM_BANK_SIZE-1))) % len(m.ram)
+}
+
+func (m *mbc6) Read(address uint16) uint8 {
+	switch {
+	case address < 0x4000:
+		{
+			return readROM(m.rom, int(address))
+		}
+	case address < memory.VRAM_START:
+		{
+			offset := m.windowOffset(address)
+			if m.showsFlash(mbc6Window(address)) {
+				return m.flash[offset%len(m.flash)]
+			}
+			return readROM(m.rom, offset)
+		}
+	}
+	if !m.ram_enabled || len(m.ram) == 0 {
+		return memory.OPEN_BUS
+	}
+	return m.ram[m.ramOffset(address)]
+}
+
+func (m *mbc6) Write(address uint16, value uint8) {
+	switch {
+	case address < 0x0400:
+		{
+			m.ram_enabled = value&0x0F == 0x0A
+		}
+	case address < 0x0800:
+		{
+			m.ram_banks[0] = value & 0x07
+		}
+	case address < 0x0C00:
+		{
+			m.ram_banks[1] = value & 0x07
+		}
+	case address < 0x1000:
+		{
+			m.flash_enabled = value&0b1 != 0
+		}
+	case address < 0x2000:
+		{
+			if address == 0x1000 {
+				m.flash_writable = value&0b1 != 0
+			}
+		}
+	case address < 0x4000:
+		{
+			window := int(address>>12) & 1
+			if address&0x0800 == 0 {
+				m.rom_banks[window] = value & 0x7F
+				return
+			}
+			m.selects[window] = value
+		}
+	case address < memory.VRAM_START:
+		{
+			if m.showsFlash(mbc6Window(address)) {
+				m.writeFlash(m.windowOffset(address)%len(m.flash), value)
+			}
+		}
+	default:
+		{
+			if m.ram_enabled && len(m.ram) != 0 {
+				m.ram[m.ramOffset(address)] = value
+			}
+		}
+	}
+}
+
+// the jedec command sequences, 0xAA to 0x5555 and 0x55 to 0x2AAA unlock
+// the chip and the third write says what to do, anything unexpected goes
+// back to reading
+func (m *mbc6) writeFlash(offset int, value uint8) {
+	if value == FLASH_RESET {
+		m.flash_state = flashIdle
+		return
+	}
+	switch m.flash_state {
+	case flashIdle, flashErase:
+		{
+			next := flashUnlocked1
+			if m.flash_state == flashErase {
+				next = flashEraseUnlocked1
+			}
+			m.flash_state = flashIdle
+			if offset == FLASH_UNLOCK_1_ADDRESS && value == FLASH_UNLOCK_1 {
+				m.flash_state = next
+			}
+		}
+	case flashUnlocked1, flashEraseUnlocked1:
+		{
+			next := flashUnlocked2
+			if m.flash_state == flashEraseUnlocked1 {
+				next = flashEraseUnlocked2
+			}
+			m.flash_state = flashIdle
+			if offset == FLASH_UNLOCK_2_ADDRESS && value == FLASH_UNLOCK_2 {
+				m.flash_state = next
+			}
+		}
+	case flashUnlocked2:
+		{
+			m.flash_state = flashIdle
+			if offset != FLASH_UNLOCK_1_ADDRESS {
+				return
+			}
+			switch value {
+			case FLASH_PROGRAM:
+				{
+					m.flash_state = flashProgram
+				}
+			case FLASH_ERASE:
+				{
+					m.flash_state = flashErase
+				}
+			}
+		}
+	case flashProgram:
+		{
+			// programming can only clear bits, erasing is what sets them
+			if m.flash_writable {
+				m.flash[offset] &= value
+			}
+			m.flash_state = flashIdle
+		}
+	case flashEraseUnlocked2:
+		{
+			m.flash_state = flashIdle
+			if !m.flash_writable {
+				return
+			}
+			switch {
+			case value == FLASH_ERASE_SECTOR:
+				{
+					start := offset / MBC6_FLASH_SECTOR_SIZE * MBC6_FLASH_SECTOR_SIZE
+					eraseFlash(m.flash[start : start+MBC6_FLASH_SECTOR_SIZE])
+				}
+			case value == FLASH_ERASE_CHIP && offset == FLASH_UNLOCK_1_ADDRESS:
+				{
+					eraseFlash(m.flash)
+				}
+			}
+		}
+	}
+}
+
+func eraseFlash(flash []uint8) {
+	for i := range flash {
+		flash[i] = 0xFF
+	}
+}
+
+// the rom or flash bank mapped at the address, or the ram bank for
+// 0xA000-0xBFFF, the numbers are in 8kb and 4kb banks
+func (m *mbc6) Bank(address uint16) uint16 {
+	switch {
+	case address < 0x4000:
+		{
+			return 0
+		}
+	case address < memory.VRAM_START:
+		{
+			return uint16(m.rom_banks[mbc6Window(address)])
+		}
+	}
+	if len(m.ram) == 0 {
+		return 0
+	}
+	return uint16(m.ramOffset(address) / MBC6_RAM_BANK_SIZE)
+}
+
+// the ram followed by the flash
+func (m *mbc6) Save() []uint8 {
+	return saveRAM(m.save)
+}
+
+// the whole save, there is no clock, it is not copied since the flash alone
+// is 1MB
+func (m *mbc6) Memory() []uint8 {
+	return m.save
+}
+
+func (m *mbc6) Load(data []uint8) error {
+	return loadRAM(m.save, data)
+}
@@ -0,0 +1,141 @@
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+func newTestMBC6() *mbc6 {
+	cartridge := newBankedROM(64, 0x20, 0x03)
+	// mark the 8kb banks too
+	for bank := range 128 {
+		cartridge.ROM[bank*MBC6_ROM_BANK_SIZE+1] = uint8(bank)
+	}
+	return newMBC6(cartridge)
+}
+
+func TestMBC6Banks(t *testing.T) {
+	mbc := newTestMBC6()
+
+	type test struct {
+		title    string
+		bank_a   uint8
+		bank_b   uint8
+		expected [2]uint8
+	}
+
+	tests := []test{
+		{title: "bank 0 in both windows", expected: [2]uint8{0, 0}},
+		{title: "different banks", bank_a: 0x05, bank_b: 0x7F, expected: [2]uint8{0x05, 0x7F}},
+		{title: "bank 7 bits", bank_a: 0x83, bank_b: 0x80, expected: [2]uint8{0x03, 0x00}},
+	}
+
+	for _, unit_test := range tests {
+		mbc.Write(0x2000, unit_test.bank_a)
+		mbc.Write(0x3000, unit_test.bank_b)
+		got := [2]uint8{mbc.Read(0x4001), mbc.Read(0x6001)}
+		if got != unit_test.expected || mbc.Bank(0x5FFF) != uint16(unit_test.expected[0]) || mbc.Bank(0x6000) != uint16(unit_test.expected[1]) {
+			t.Errorf("failed : %s expected : %v got : %v", unit_test.title, unit_test.expected, got)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+
+	// two 4kb ram windows
+	mbc.Write(0x0000, 0x0A)
+	mbc.Write(0x0400, 0x02)
+	mbc.Write(0x0800, 0x05)
+	mbc.Write(0xA000, 0x22)
+	mbc.Write(0xB000, 0x55)
+	mbc.Write(0x0400, 0x05)
+	if value := mbc.Read(0xA000); value != 0x55 || mbc.Bank(0xAFFF) != 5 {
+		t.Errorf("failed : expected ram bank 5 in both windows got : 0x%02X", value)
+	}
+	mbc.Write(0x0800, 0x02)
+	if value := mbc.Read(0xB000); value != 0x22 || mbc.Bank(0xB000) != 2 {
+		t.Errorf("failed : expected ram bank 2 at 0xB000 got : 0x%02X", value)
+	}
+	mbc.Write(0x0000, 0x00)
+	if mbc.Read(0xA000) != memory.OPEN_BUS {
+		t.Errorf("failed : disabled ram expected open bus")
+	}
+}
+
+func TestMBC6Flash(t *testing.T) {
+	mbc := newTestMBC6()
+	mbc.Write(0x0C00, 0x01)
+	mbc.Write(0x1000, 0x01)
+	mbc.Write(0x2800, MBC6_SELECT_FLASH)
+	mbc.Write(0x3800, MBC6_SELECT_FLASH)
+
+	// with bank 2 at 0x4000 and bank 1 at 0x6000 the unlock addresses are
+	// 0x5555 and 0x6AAA
+	command := func(value uint8) {
+		mbc.Write(0x2000, 0x02)
+		mbc.Write(0x3000, 0x01)
+		mbc.Write(0x5555, FLASH_UNLOCK_1)
+		mbc.Write(0x6AAA, FLASH_UNLOCK_2)
+		mbc.Write(0x5555, value)
+	}
+
+	command(FLASH_PROGRAM)
+	mbc.Write(0x2000, 0x10)
+	mbc.Write(0x4123, 0x3C)
+	if value := mbc.Read(0x4123); value != 0x3C {
+		t.Errorf("failed : expected the flash to be programmed got : 0x%02X", value)
+	}
+	// writes outside a command dont do anything
+	mbc.Write(0x4123, 0x00)
+	if value := mbc.Read(0x4123); value != 0x3C {
+		t.Errorf("failed : expected a write without command to be ignored got : 0x%02X", value)
+	}
+	// the rom is still there with the select at 0
+	mbc.Write(0x2800, 0x00)
+	if value := mbc.Read(0x4001); value != 0x10 {
+		t.Errorf("failed : expected rom bank 0x10 got : 0x%02X", value)
+	}
+	mbc.Write(0x2800, MBC6_SELECT_FLASH)
+
+	command(FLASH_ERASE)
+	mbc.Write(0x2000, 0x10)
+	mbc.Write(0x5555, FLASH_UNLOCK_1)
+	mbc.Write(0x2000, 0x02)
+	mbc.Write(0x5555, FLASH_UNLOCK_1)
+	mbc.Write(0x6AAA, FLASH_UNLOCK_2)
+	mbc.Write(0x2000, 0x10)
+	mbc.Write(0x4000, FLASH_ERASE_SECTOR)
+	if value := mbc.Read(0x4123); value != 0x3C {
+		t.Errorf("failed : expected a broken erase sequence to be ignored got : 0x%02X", value)
+	}
+
+	command(FLASH_ERASE)
+	mbc.Write(0x5555, FLASH_UNLOCK_1)
+	mbc.Write(0x6AAA, FLASH_UNLOCK_2)
+	mbc.Write(0x2000, 0x10)
+	mbc.Write(0x4000, FLASH_ERASE_SECTOR)
+	if value := mbc.Read(0x4123); value != 0xFF {
+		t.Errorf("failed : expected the sector to be erased got : 0x%02X", value)
+	}
+
+	// the write enable at 0x1000 protects the flash
+	mbc.Write(0x1000, 0x00)
+	command(FLASH_PROGRAM)
+	mbc.Write(0x2000, 0x10)
+	mbc.Write(0x4123, 0x00)
+	if value := mbc.Read(0x4123); value != 0xFF {
+		t.Errorf("failed : expected the flash to be protected got : 0x%02X", value)
+	}
+
+	save := mbc.Save()
+	if len(save) != 0x8000+MBC6_FLASH_SIZE {
+		t.Errorf("failed : expected the ram and the flash in the save got : %d bytes", len(save))
+	}
+	if err := newTestMBC6().Load(save); err != nil {
+		t.Errorf("failed : load %v", err)
+	}
+	// the save file compares it every second, it cant copy 1MB each time
+	if allocs := testing.AllocsPerRun(10, func() { mbc.Memory() }); allocs != 0 {
+		t.Errorf("failed : expected Memory not to allocate got : %.0f", allocs)
+	}
+}
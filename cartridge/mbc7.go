@@ -0,0 +1,407 @@
+package cartridge
+
+import "github.com/chilepikmin/gamegorl/memory"
+
+// Accelerometer is implemented by the mappers with a tilt sensor, the
+// frontend feeds it from its input with the tilt of each axis in g, right and
+// down are positive
+type Accelerometer interface {
+	Tilt(x, y float64)
+}
+
+const (
+	// what the sensor reads when the game boy is flat and how much 1g moves it
+	MBC7_ACCELEROMETER_CENTER = 0x81D0
+	MBC7_ACCELEROMETER_G      = 0x70
+
+	// writing these to 0xA000 and 0xA010 erases and then latches the sensor
+	MBC7_ERASE = 0x55
+	MBC7_LATCH = 0xAA
+)
+
+// the bits of the eeprom register at 0xA080
+const (
+	EEPROM_DO  = 1 << 0
+	EEPROM_DI  = 1 << 1
+	EEPROM_CLK = 1 << 6
+	EEPROM_CS  = 1 << 7
+)
+
+// MBC7, kirby tilt n tumble and command master, up to 2MB of rom, a 2 axis
+// accelerometer and a 93LC56 eeprom in place of the ram, both behind
+// registers at 0xA000-0xAFFF that need the two ram enables
+// https://gbdev.io/pandocs/MBC7.html
+type mbc7 struct {
+	rom       []uint8
+	rom_banks int
+	eeprom    eeprom
+	// the words as bytes for Memory, refreshed in place every call
+	memory [2 * EEPROM_WORDS]uint8
+
+	// 0x0000-0x1FFF needs 0x0A and 0x4000-0x5FFF 0x40
+	ram_enabled  bool
+	ram_enabled2 bool
+	// 0x2000-0x3FFF
+	rom_bank uint8
+
+	// what the frontend says and what the game latched
+	tilt_x, tilt_y uint16
+	x, y           uint16
+	erased         bool
+}
+
+func newMBC7(cartridge *Cartridge) *mbc7 {
+	mbc := &mbc7{
+		rom:       cartridge.ROM,
+		rom_banks: romBanks(cartridge.ROM),
+		rom_bank:  1,
+		tilt_x:    MBC7_ACCELEROMETER_CENTER,
+		tilt_y:    MBC7_ACCELEROMETER_CENTER,
+		x:         0x8000,
+		y:         0x8000,
+	}
+	mbc.eeprom.reset()
+	// a blank eeprom is all ones, the games look for that to start a new save
+	for i := range mbc.eeprom.words {
+		mbc.eeprom.words[i] = 0xFFFF
+	}
+	return mbc
+}
+
+func (m *mbc7) Tilt(x, y float64) {
+	m.tilt_x = mbc7Axis(x)
+	m.tilt_y = mbc7Axis(y)
+}
+
+// the sensor saturates, a tilt past what fits stays at the end it went to
+// instead of wrapping around to the other side
+func mbc7Axis(tilt float64) uint16 {
+	offset := max(-MBC7_ACCELEROMETER_CENTER, min(tilt*MBC7_ACCELEROMETER_G, 0xFFFF-MBC7_ACCELEROMETER_CENTER))
+	return uint16(MBC7_ACCELEROMETER_CENTER + int(offset))
+}
+
+func (m *mbc7) highBank() int {
+	return int(m.rom_bank) % m.rom_banks
+}
+
+func (m *mbc7) registersEnabled() bool {
+	return m.ram_enabled && m.ram_enabled2
+}
+
+func (m *mbc7) Read(address uint16) uint8 {
+	switch {
+	case address < 0x4000:
+		{
+			return readROM(m.rom, int(address))
+		}
+	case address < memory.VRAM_START:
+		{
+			return readROM(m.rom, m.highBank()*ROM_BANK_SIZE+int(address-0x4000))
+		}
+	}
+	if !m.registersEnabled() || address >= 0xB000 {
+		return memory.OPEN_BUS
+	}
+	// bits 4-7 of the address select the register
+	switch address >> 4 & 0x0F {
+	case 0x2:
+		{
+			return uint8(m.x)
+		}
+	case 0x3:
+		{
+			return uint8(m.x >> 8)
+		}
+	case 0x4:
+		{
+			return uint8(m.y)
+		}
+	case 0x5:
+		{
+			return uint8(m.y >> 8)
+		}
+	case 0x6:
+		{
+			return 0x00
+		}
+	case 0x8:
+		{
+			return m.eeprom.read()
+		}
+	}
+	return memory.OPEN_BUS
+}
+
+func (m *mbc7) Write(address uint16, value uint8) {
+	switch {
+	case address < 0x2000:
+		{
+			m.ram_enabled = value == 0x0A
+		}
+	case address < 0x4000:
+		{
+			m.rom_bank = value & 0x7F
+		}
+	case address < 0x6000:
+		{
+			m.ram_enabled2 = value == 0x40
+		}
+	case address < memory.VRAM_START:
+		{
+			// nothing is wired to 0x6000-0x7FFF
+		}
+	default:
+		{
+			if !m.registersEnabled() || address >= 0xB000 {
+				return
+			}
+			switch address >> 4 & 0x0F {
+			case 0x0:
+				{
+					if value == MBC7_ERASE {
+						m.x, m.y = 0x8000, 0x8000
+						m.erased = true
+					}
+				}
+			case 0x1:
+				{
+					// only latches once after every erase
+					if value == MBC7_LATCH && m.erased {
+						m.x, m.y = m.tilt_x, m.tilt_y
+						m.erased = false
+					}
+				}
+			case 0x8:
+				{
+					m.eeprom.write(value)
+				}
+			}
+		}
+	}
+}
+
+// the rom bank mapped at the address, there is no banked ram
+func (m *mbc7) Bank(address uint16) uint16 {
+	if address >= 0x4000 && address < memory.VRAM_START {
+		return uint16(m.highBank())
+	}
+	return 0
+}
+
+// the eeprom words, low byte first
+func (m *mbc7) Save() []uint8 {
+	return saveRAM(m.Memory())
+}
+
+// the whole save, there is no clock
+func (m *mbc7) Memory() []uint8 {
+	for i, word := range m.eeprom.words {
+		m.memory[2*i] = uint8(word)
+		m.memory[2*i+1] = uint8(word >> 8)
+	}
+	return m.memory[:]
+}
+
+func (m *mbc7) Load(data []uint8) error {
+	if len(data) != 2*len(m.eeprom.words) {
+		return &SaveSizeError{Expected: 2 * len(m.eeprom.words), Got: len(data)}
+	}
+	for i := range m.eeprom.words {
+		m.eeprom.words[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+	}
+	return nil
+}
+
+// the 93LC56 commands, a start bit, 2 bits of opcode and 8 of address
+const (
+	EEPROM_EXTENDED = 0b00
+	EEPROM_WRITE    = 0b01
+	EEPROM_READ     = 0b10
+	EEPROM_ERASE    = 0b11
+
+	// the extended commands use the top 2 bits of the address
+	EEPROM_WRITE_DISABLE = 0b00
+	EEPROM_WRITE_ALL     = 0b01
+	EEPROM_ERASE_ALL     = 0b10
+	EEPROM_WRITE_ENABLE  = 0b11
+
+	EEPROM_COMMAND_BITS = 10
+	EEPROM_WORDS        = 128
+)
+
+type eepromState uint8
+
+const (
+	eepromIdle eepromState = iota
+	eepromCommand
+	eepromReading
+	eepromWriting
+	eepromDone
+)
+
+// a 256 byte serial eeprom organized in 16 bit words, the game bit bangs it
+// through the register at 0xA080, the bits go in and out on the rising
+// edges of CLK while CS is high
+type eeprom struct {
+	words [EEPROM_WORDS]uint16
+
+	cs, clk, di, do bool
+	writable        bool
+
+	state   eepromState
+	shift   uint16
+	bits    int
+	command uint16
+}
+
+func (e *eeprom) reset() {
+	e.state = eepromIdle
+	e.shift = 0
+	e.bits = 0
+	// ready
+	e.do = true
+}
+
+func (e *eeprom) read() uint8 {
+	value := uint8(0)
+	if e.cs {
+		value |= EEPROM_CS
+	}
+	if e.clk {
+		value |= EEPROM_CLK
+	}
+	if e.di {
+		value |= EEPROM_DI
+	}
+	if e.do {
+		value |= EEPROM_DO
+	}
+	return value
+}
+
+func (e *eeprom) write(value uint8) {
+	cs, clk := value&EEPROM_CS != 0, value&EEPROM_CLK != 0
+	e.di = value&EEPROM_DI != 0
+	rising := clk && !e.clk
+	e.cs, e.clk = cs, clk
+	if !cs {
+		e.reset()
+		return
+	}
+	if rising {
+		e.clock()
+	}
+}
+
+func (e *eeprom) clock() {
+	bit := uint16(0)
+	if e.di {
+		bit = 1
+	}
+	switch e.state {
+	case eepromIdle:
+		{
+			if bit == 1 {
+				e.state = eepromCommand
+				e.shift, e.bits = 0, 0
+			}
+		}
+	case eepromCommand:
+		{
+			e.shift = e.shift<<1 | bit
+			e.bits++
+			if e.bits == EEPROM_COMMAND_BITS {
+				e.command = e.shift
+				e.run()
+			}
+		}
+	case eepromReading:
+		{
+			e.do = e.shift&0x8000 != 0
+			e.shift <<= 1
+			e.bits--
+			if e.bits == 0 {
+				e.state = eepromDone
+			}
+		}
+	case eepromWriting:
+		{
+			e.shift = e.shift<<1 | bit
+			e.bits++
+			if e.bits == 16 {
+				e.store(e.shift)
+				e.state = eepromDone
+				e.do = true
+			}
+		}
+	}
+}
+
+func (e *eeprom) address() int {
+	return int(e.command) & 0x7F
+}
+
+// the command and its address are in, READ shows a dummy 0 on DO before the
+// 16 bits of the word
+func (e *eeprom) run() {
+	e.state = eepromDone
+	switch e.command >> 8 {
+	case EEPROM_READ:
+		{
+			e.state = eepromReading
+			e.shift, e.bits = e.words[e.address()], 16
+			e.do = false
+		}
+	case EEPROM_WRITE:
+		{
+			e.state = eepromWriting
+			e.shift, e.bits = 0, 0
+		}
+	case EEPROM_ERASE:
+		{
+			if e.writable {
+				e.words[e.address()] = 0xFFFF
+			}
+		}
+	case EEPROM_EXTENDED:
+		{
+			switch e.command >> 6 & 0b11 {
+			case EEPROM_WRITE_DISABLE:
+				{
+					e.writable = false
+				}
+			case EEPROM_WRITE_ENABLE:
+				{
+					e.writable = true
+				}
+			case EEPROM_ERASE_ALL:
+				{
+					if e.writable {
+						for i := range e.words {
+							e.words[i] = 0xFFFF
+						}
+					}
+				}
+			case EEPROM_WRITE_ALL:
+				{
+					e.state = eepromWriting
+					e.shift, e.bits = 0, 0
+				}
+			}
+		}
+	}
+}
+
+// WRITE stores in the word of the command and WRAL in all of them
+func (e *eeprom) store(word uint16) {
+	if !e.writable {
+		return
+	}
+	if e.command>>8 == EEPROM_WRITE {
+		e.words[e.address()] = word
+		return
+	}
+	for i := range e.words {
+		e.words[i] = word
+	}
+}
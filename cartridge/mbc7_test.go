@@ -0,0 +1,157 @@
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+func newTestMBC7() *mbc7 {
+	mbc := newMBC7(newBankedROM(64, 0x22, 0x00))
+	mbc.Write(0x0000, 0x0A)
+	mbc.Write(0x4000, 0x40)
+	return mbc
+}
+
+// bit bangs the bits into the eeprom msb first
+func sendEEPROM(mbc *mbc7, value uint32, bits int) {
+	for bit := bits - 1; bit >= 0; bit-- {
+		di := uint8(value>>bit&1) * EEPROM_DI
+		mbc.Write(0xA080, EEPROM_CS|di)
+		mbc.Write(0xA080, EEPROM_CS|EEPROM_CLK|di)
+	}
+}
+
+func readEEPROM(mbc *mbc7, address uint8) uint16 {
+	sendEEPROM(mbc, 1<<10|EEPROM_READ<<8|uint32(address), 11)
+	word := uint16(0)
+	for range 16 {
+		mbc.Write(0xA080, EEPROM_CS)
+		mbc.Write(0xA080, EEPROM_CS|EEPROM_CLK)
+		word = word<<1 | uint16(mbc.Read(0xA080)&EEPROM_DO)
+	}
+	mbc.Write(0xA080, 0x00)
+	return word
+}
+
+func TestMBC7Banks(t *testing.T) {
+	mbc := newMBC7(newBankedROM(128, 0x22, 0x00))
+	for bank := range 256 {
+		mbc.Write(0x2000, uint8(bank))
+		if got := int(mbc.Read(0x4000)); got != bank%128 || int(mbc.Bank(0x4000)) != bank%128 {
+			t.Errorf("failed : rom bank 0x%02X expected : %d got : %d", bank, bank%128, got)
+		}
+	}
+
+	// the registers need both enables
+	mbc.Write(0x0000, 0x0A)
+	if mbc.Read(0xA080) != memory.OPEN_BUS {
+		t.Errorf("failed : expected the registers to need 0x40 at 0x4000")
+	}
+	mbc.Write(0x4000, 0x40)
+	if mbc.Read(0xA060) != 0x00 || mbc.Read(0xA070) != 0xFF || mbc.Read(0xB000) != memory.OPEN_BUS {
+		t.Errorf("failed : unexpected fixed registers")
+	}
+}
+
+func TestMBC7Accelerometer(t *testing.T) {
+	mbc := newTestMBC7()
+	readAxes := func() (uint16, uint16) {
+		x := uint16(mbc.Read(0xA020)) | uint16(mbc.Read(0xA030))<<8
+		y := uint16(mbc.Read(0xA040)) | uint16(mbc.Read(0xA050))<<8
+		return x, y
+	}
+
+	type test struct {
+		title      string
+		x, y       float64
+		erase      bool
+		expected_x uint16
+		expected_y uint16
+	}
+
+	tests := []test{
+		{title: "flat", erase: true, expected_x: 0x81D0, expected_y: 0x81D0},
+		{title: "tilted right", x: 1, y: -0.5, erase: true, expected_x: 0x81D0 + 0x70, expected_y: 0x81D0 - 0x38},
+		{title: "latch without erase", x: -1, expected_x: 0x81D0 + 0x70, expected_y: 0x81D0 - 0x38},
+		{title: "too far left and down", x: -300, y: 300, erase: true, expected_x: 0x0000, expected_y: 0xFFFF},
+	}
+
+	for _, unit_test := range tests {
+		mbc.Tilt(unit_test.x, unit_test.y)
+		if unit_test.erase {
+			mbc.Write(0xA000, MBC7_ERASE)
+			if x, y := readAxes(); x != 0x8000 || y != 0x8000 {
+				t.Errorf("failed : %s expected the erase to leave 0x8000 got : 0x%04X 0x%04X", unit_test.title, x, y)
+			}
+		}
+		mbc.Write(0xA010, MBC7_LATCH)
+		if x, y := readAxes(); x != unit_test.expected_x || y != unit_test.expected_y {
+			t.Errorf("failed : %s expected : 0x%04X 0x%04X got : 0x%04X 0x%04X", unit_test.title, unit_test.expected_x, unit_test.expected_y, x, y)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+}
+
+func TestMBC7EEPROM(t *testing.T) {
+	mbc := newTestMBC7()
+	write := func(address uint8, word uint16) {
+		sendEEPROM(mbc, 1<<26|EEPROM_WRITE<<24|uint32(address)<<16|uint32(word), 27)
+		mbc.Write(0xA080, 0x00)
+	}
+	extended := func(command uint32) {
+		sendEEPROM(mbc, 1<<10|EEPROM_EXTENDED<<8|command<<6, 11)
+		mbc.Write(0xA080, 0x00)
+	}
+
+	// it starts blank
+	for _, address := range []uint8{0x00, 0x10, 0x7F} {
+		if word := readEEPROM(mbc, address); word != 0xFFFF {
+			t.Errorf("failed : expected a blank eeprom at 0x%02X got : 0x%04X", address, word)
+		}
+	}
+
+	// writes are ignored until they are enabled
+	write(0x10, 0x1234)
+	if word := readEEPROM(mbc, 0x10); word != 0xFFFF {
+		t.Errorf("failed : expected the write to be ignored got : 0x%04X", word)
+	}
+	extended(EEPROM_WRITE_ENABLE)
+	write(0x10, 0x1234)
+	write(0x7F, 0xBEEF)
+	if word := readEEPROM(mbc, 0x10); word != 0x1234 {
+		t.Errorf("failed : expected : 0x1234 got : 0x%04X", word)
+	}
+	if word := readEEPROM(mbc, 0x7F); word != 0xBEEF {
+		t.Errorf("failed : expected : 0xBEEF got : 0x%04X", word)
+	}
+	if mbc.Read(0xA080)&EEPROM_DO == 0 {
+		t.Errorf("failed : expected DO to be ready when idle")
+	}
+
+	sendEEPROM(mbc, 1<<10|EEPROM_ERASE<<8|0x10, 11)
+	mbc.Write(0xA080, 0x00)
+	if word := readEEPROM(mbc, 0x10); word != 0xFFFF {
+		t.Errorf("failed : expected the word to be erased got : 0x%04X", word)
+	}
+
+	save := mbc.Save()
+	if len(save) != 256 || save[0xFE] != 0xEF || save[0xFF] != 0xBE {
+		t.Errorf("failed : unexpected save of %d bytes", len(save))
+	}
+	extended(EEPROM_ERASE_ALL)
+	if word := readEEPROM(mbc, 0x7F); word != 0xFFFF {
+		t.Errorf("failed : expected everything to be erased got : 0x%04X", word)
+	}
+	extended(EEPROM_WRITE_DISABLE)
+	if err := mbc.Load(save); err != nil || readEEPROM(mbc, 0x7F) != 0xBEEF {
+		t.Errorf("failed : expected the save to come back %v", err)
+	}
+	if memory := mbc.Memory(); memory[0xFE] != 0xEF || memory[0xFF] != 0xBE {
+		t.Errorf("failed : expected the memory to follow the eeprom")
+	}
+	if allocs := testing.AllocsPerRun(10, func() { mbc.Memory() }); allocs != 0 {
+		t.Errorf("failed : expected Memory not to allocate got : %.0f", allocs)
+	}
+}
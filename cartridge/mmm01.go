@@ -0,0 +1,205 @@
+package cartridge
+
+import (
+	"bytes"
+
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+// MMM01, the mapper of the multicarts with a menu, it starts with the last
+// 32kb of the rom (where the menu is) mapped and once the menu picks a game
+// and sets the map bit it behaves like an MBC1 limited to the game
+// https://gbdev.io/pandocs/MMM01.html
+type mmm01 struct {
+	rom       []uint8
+	ram       []uint8
+	rom_banks int
+
+	// 0x0000-0x1FFF, bits 0-3 enable the ram, bits 4-5 lock bits of the ram
+	// bank and bit 6 maps the game and locks the menu registers
+	ram_enabled bool
+	ram_mask    uint8
+	mapped      bool
+	// 0x2000-0x3FFF, bits 0-4 and the menu only bits 5-6
+	rom_bank_low uint8
+	rom_bank_mid uint8
+	// 0x4000-0x5FFF, bits 0-1 and the menu only bits 2-3 of the ram bank,
+	// bits 4-5 are the menu only bits 7-8 of the rom bank and bit 6 locks
+	// the mode
+	ram_bank_low  uint8
+	ram_bank_high uint8
+	rom_bank_high uint8
+	mode_locked   bool
+	// 0x6000-0x7FFF, bit 0 is the MBC1 mode and the menu only bits 2-5 lock
+	// bits 1-4 of the rom bank
+	mode     uint8
+	rom_mask uint8
+}
+
+func newMMM01(cartridge *Cartridge) *mmm01 {
+	menu := cartridge.Header
+	if isMMM01(cartridge.ROM) {
+		menu.RAMSize = cartridge.ROM[len(cartridge.ROM)-2*ROM_BANK_SIZE+RAM_SIZE_ADDRESS]
+	}
+	return &mmm01{
+		rom:       cartridge.ROM,
+		ram:       make([]uint8, menu.RAMBytes()),
+		rom_banks: romBanks(cartridge.ROM),
+	}
+}
+
+// the menu has its header (and the logo) at the start of the last 32kb, the
+// first bank usually has the header of one of the games
+func isMMM01(rom []uint8) bool {
+	_, ok := mmm01Type(rom)
+	return ok
+}
+
+// the cartridge type in the header of the menu, that one says if the
+// multicart has ram and a battery and not the one of the first game
+func mmm01Type(rom []uint8) (Type, bool) {
+	if len(rom) < 2*ROM_BANK_SIZE {
+		return 0, false
+	}
+	header := len(rom) - 2*ROM_BANK_SIZE
+	if !bytes.Equal(rom[header+LOGO_ADDRESS:header+LOGO_ADDRESS+len(LOGO)], LOGO[:]) {
+		return 0, false
+	}
+	cartridge_type := Type(rom[header+TYPE_ADDRESS])
+	features, ok := cartridge_type.Features()
+	return cartridge_type, ok && features.MBC == MMM01
+}
+
+// the rom bank bits the game can change, the rest are fixed by the menu
+func (m *mmm01) romBankBits() uint8 {
+	return 0x1F &^ (m.rom_mask << 1)
+}
+
+func (m *mmm01) romBankBase() int {
+	return int(m.rom_bank_high)<<7 | int(m.rom_bank_mid)<<5
+}
+
+func (m *mmm01) lowBank() int {
+	if !m.mapped {
+		return 0x1FE % m.rom_banks
+	}
+	return (m.romBankBase() | int(m.rom_bank_low&^m.romBankBits())) % m.rom_banks
+}
+
+// like MBC1 0 selects 1, but only the bits the game controls count
+func (m *mmm01) highBank() int {
+	if !m.mapped {
+		return 0x1FF % m.rom_banks
+	}
+	low := m.rom_bank_low
+	if low&m.romBankBits() == 0 {
+		low |= 1
+	}
+	return (m.romBankBase() | int(low)) % m.rom_banks
+}
+
+func (m *mmm01) ramOffset(address uint16) int {
+	bank := m.ram_bank_high << 2
+	if m.mode == 1 {
+		bank |= m.ram_bank_low
+	}
+	return (int(bank)*RAM_BANK_SIZE + int(address-memory.EXTERNAL_RAM_START)) % len(m.ram)
+}
+
+func (m *mmm01) Read(address uint16) uint8 {
+	switch {
+	case address < 0x4000:
+		{
+			return readROM(m.rom, m.lowBank()*ROM_BANK_SIZE+int(address))
+		}
+	case address < memory.VRAM_START:
+		{
+			return readROM(m.rom, m.highBank()*ROM_BANK_SIZE+int(address-0x4000))
+		}
+	}
+	if !m.ram_enabled || len(m.ram) == 0 {
+		return memory.OPEN_BUS
+	}
+	return m.ram[m.ramOffset(address)]
+}
+
+// keeps the locked bits of old and takes the rest from value
+func lockedWrite(old, value, locked uint8) uint8 {
+	return old&locked | value&^locked
+}
+
+func (m *mmm01) Write(address uint16, value uint8) {
+	switch {
+	case address < 0x2000:
+		{
+			m.ram_enabled = value&0x0F == 0x0A
+			if !m.mapped {
+				m.ram_mask = value >> 4 & 0b11
+				m.mapped = value&0x40 != 0
+			}
+		}
+	case address < 0x4000:
+		{
+			m.rom_bank_low = lockedWrite(m.rom_bank_low, value&0x1F, ^m.romBankBits()&0x1F)
+			if !m.mapped {
+				m.rom_bank_low = value & 0x1F
+				m.rom_bank_mid = value >> 5 & 0b11
+			}
+		}
+	case address < 0x6000:
+		{
+			m.ram_bank_low = lockedWrite(m.ram_bank_low, value&0b11, m.ram_mask)
+			if !m.mapped {
+				m.ram_bank_low = value & 0b11
+				m.ram_bank_high = value >> 2 & 0b11
+				m.rom_bank_high = value >> 4 & 0b11
+				m.mode_locked = value&0x40 != 0
+			}
+		}
+	case address < memory.VRAM_START:
+		{
+			if !m.mode_locked {
+				m.mode = value & 0b1
+			}
+			if !m.mapped {
+				m.rom_mask = value >> 2 & 0x0F
+			}
+		}
+	default:
+		{
+			if m.ram_enabled && len(m.ram) != 0 {
+				m.ram[m.ramOffset(address)] = value
+			}
+		}
+	}
+}
+
+// the rom bank mapped at the address, or the ram bank for 0xA000-0xBFFF
+func (m *mmm01) Bank(address uint16) uint16 {
+	switch {
+	case address < 0x4000:
+		{
+			return uint16(m.lowBank())
+		}
+	case address < memory.VRAM_START:
+		{
+			return uint16(m.highBank())
+		}
+	}
+	if len(m.ram) == 0 {
+		return 0
+	}
+	return uint16(m.ramOffset(address) / RAM_BANK_SIZE)
+}
+
+func (m *mmm01) Save() []uint8 {
+	return saveRAM(m.ram)
+}
+
+func (m *mmm01) Memory() []uint8 {
+	return m.ram
+}
+
+func (m *mmm01) Load(data []uint8) error {
+	return loadRAM(m.ram, data)
+}
@@ -0,0 +1,72 @@
+package cartridge
+
+import "testing"
+
+// 4 games of 128kb with the menu in the last 32kb
+func newTestMMM01() *Cartridge {
+	cartridge := newBankedROM(32, 0x01, 0x00)
+	menu := len(cartridge.ROM) - 2*ROM_BANK_SIZE
+	copy(cartridge.ROM[menu+LOGO_ADDRESS:], LOGO[:])
+	cartridge.ROM[menu+TYPE_ADDRESS] = 0x0D
+	cartridge.ROM[menu+RAM_SIZE_ADDRESS] = 0x03
+	return cartridge
+}
+
+func TestMMM01(t *testing.T) {
+	cartridge := newTestMMM01()
+	mapper, err := NewMapper(cartridge)
+	if err != nil {
+		t.Fatalf("failed : %v", err)
+	}
+	mbc, ok := mapper.(*mmm01)
+	if !ok || len(mbc.ram) != 0x8000 {
+		t.Fatalf("failed : expected an MMM01 with the ram of the menu header")
+	}
+
+	// the menu starts mapped
+	if mbc.Read(0x0000) != 30 || mbc.Read(0x4000) != 31 {
+		t.Errorf("failed : expected the last 32kb got banks : %d %d", mbc.Read(0x0000), mbc.Read(0x4000))
+	}
+
+	// the menu picks the game at bank 0x10 and locks bits 3-4 of the rom
+	// bank (a 128kb game) and maps it
+	mbc.Write(0x2000, 0x10)
+	mbc.Write(0x6000, 0b1100<<2)
+	mbc.Write(0x0000, 0x40|0x0A)
+
+	type test struct {
+		title string
+		bank  uint8
+		low   int
+		high  int
+	}
+
+	tests := []test{
+		{title: "game bank 0", bank: 0x00, low: 0x10, high: 0x11},
+		{title: "game bank 5", bank: 0x05, low: 0x10, high: 0x15},
+		{title: "game bank 7", bank: 0x07, low: 0x10, high: 0x17},
+		{title: "locked bits ignored", bank: 0x1A, low: 0x10, high: 0x12},
+	}
+
+	for _, unit_test := range tests {
+		mbc.Write(0x2000, unit_test.bank)
+		low, high := int(mbc.Read(0x0000)), int(mbc.Read(0x4000))
+		if low != unit_test.low || high != unit_test.high || int(mbc.Bank(0x4000)) != unit_test.high {
+			t.Errorf("failed : %s expected : %d %d got : %d %d", unit_test.title, unit_test.low, unit_test.high, low, high)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+
+	// the menu registers are locked now
+	mbc.Write(0x6000, 0x00)
+	mbc.Write(0x0000, 0x0A)
+	mbc.Write(0x2000, 0x01)
+	if bank := mbc.Read(0x4000); bank != 0x11 {
+		t.Errorf("failed : expected the game to stay mapped got bank : %d", bank)
+	}
+	mbc.Write(0xA000, 0x77)
+	if value := mbc.Read(0xA000); value != 0x77 {
+		t.Errorf("failed : expected the ram to work got : 0x%02X", value)
+	}
+}
@@ -0,0 +1,252 @@
+package cartridge
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Clock is where the cartridges with a real time clock get the time from,
+// the tests use one they can move forward by hand
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Clocked is implemented by the mappers with a real time clock
+type Clocked interface {
+	SetClock(clock Clock)
+}
+
+// the MBC3 clock registers, selected by writing 0x08-0x0C to 0x4000
+const (
+	RTC_SECONDS = iota + 0x08
+	RTC_MINUTES
+	RTC_HOURS
+	RTC_DAY_LOW
+	// bit 0 is bit 8 of the day counter, bit 6 halts the clock and bit 7 is
+	// set when the day counter overflows and stays set until it is cleared
+	RTC_DAY_HIGH
+)
+
+const (
+	RTC_DAY_HIGH_BIT = 0b1
+	RTC_HALT         = 1 << 6
+	RTC_CARRY        = 1 << 7
+
+	// 5 registers, their latched copy and the unix time of the save
+	RTC_FOOTER_SIZE = 48
+	// the same without the upper 4 bytes of the time, some emulators write
+	// this one
+	RTC_FOOTER_SIZE_32 = 44
+)
+
+type rtcRegisters struct {
+	seconds uint8
+	minutes uint8
+	hours   uint8
+	days    uint16
+	halt    bool
+	carry   bool
+}
+
+// the real time clock of the MBC3, it counts while the game boy is off so
+// it is brought up to date from the clock every time it is used
+type rtc struct {
+	clock   Clock
+	live    rtcRegisters
+	latched rtcRegisters
+	// when the live registers were last brought up to date and the part of a
+	// second that was left over
+	updated    time.Time
+	subsecond  time.Duration
+	latch_last uint8
+}
+
+func newRTC(clock Clock) *rtc {
+	return &rtc{clock: clock, updated: clock.Now(), latch_last: 0xFF}
+}
+
+func (r *rtc) setClock(clock Clock) {
+	r.update()
+	r.clock = clock
+	r.updated = clock.Now()
+}
+
+func (r *rtc) update() {
+	now := r.clock.Now()
+	elapsed := now.Sub(r.updated) + r.subsecond
+	r.updated = now
+	if r.live.halt || elapsed < 0 {
+		r.subsecond = 0
+		return
+	}
+	r.subsecond = elapsed % time.Second
+	r.live.advance(uint64(elapsed / time.Second))
+}
+
+// moves the registers forward, the games can write values that are out of
+// range (61 seconds, 25 hours...) and the counters just keep going until
+// their bits overflow without carrying into the next one
+func (regs *rtcRegisters) advance(seconds uint64) {
+	for seconds > 0 && (regs.seconds > 59 || regs.minutes > 59 || regs.hours > 23) {
+		regs.tick()
+		seconds--
+	}
+	if seconds == 0 {
+		return
+	}
+	total := uint64(regs.seconds) + 60*uint64(regs.minutes) + 3600*uint64(regs.hours) + 86400*uint64(regs.days) + seconds
+	days := total / 86400
+	if days > 0x1FF {
+		regs.carry = true
+		days &= 0x1FF
+	}
+	regs.days = uint16(days)
+	regs.hours = uint8(total % 86400 / 3600)
+	regs.minutes = uint8(total % 3600 / 60)
+	regs.seconds = uint8(total % 60)
+}
+
+func (regs *rtcRegisters) tick() {
+	regs.seconds = (regs.seconds + 1) & 0x3F
+	if regs.seconds != 60 {
+		return
+	}
+	regs.seconds = 0
+	regs.minutes = (regs.minutes + 1) & 0x3F
+	if regs.minutes != 60 {
+		return
+	}
+	regs.minutes = 0
+	regs.hours = (regs.hours + 1) & 0x1F
+	if regs.hours != 24 {
+		return
+	}
+	regs.hours = 0
+	regs.days++
+	if regs.days > 0x1FF {
+		regs.days = 0
+		regs.carry = true
+	}
+}
+
+func (regs *rtcRegisters) read(register uint8) uint8 {
+	switch register {
+	case RTC_SECONDS:
+		{
+			return regs.seconds
+		}
+	case RTC_MINUTES:
+		{
+			return regs.minutes
+		}
+	case RTC_HOURS:
+		{
+			return regs.hours
+		}
+	case RTC_DAY_LOW:
+		{
+			return uint8(regs.days)
+		}
+	}
+	value := uint8(regs.days >> 8)
+	if regs.halt {
+		value |= RTC_HALT
+	}
+	if regs.carry {
+		value |= RTC_CARRY
+	}
+	return value
+}
+
+func (regs *rtcRegisters) write(register uint8, value uint8) {
+	switch register {
+	case RTC_SECONDS:
+		{
+			regs.seconds = value & 0x3F
+		}
+	case RTC_MINUTES:
+		{
+			regs.minutes = value & 0x3F
+		}
+	case RTC_HOURS:
+		{
+			regs.hours = value & 0x1F
+		}
+	case RTC_DAY_LOW:
+		{
+			regs.days = regs.days&0x100 | uint16(value)
+		}
+	case RTC_DAY_HIGH:
+		{
+			regs.days = regs.days&0xFF | uint16(value&RTC_DAY_HIGH_BIT)<<8
+			regs.halt = value&RTC_HALT != 0
+			regs.carry = value&RTC_CARRY != 0
+		}
+	}
+}
+
+// the games read the latched copy so the value doesnt change while they are
+// reading the 5 registers
+func (r *rtc) read(register uint8) uint8 {
+	return r.latched.read(register)
+}
+
+func (r *rtc) write(register uint8, value uint8) {
+	r.update()
+	r.live.write(register, value)
+	r.latched.write(register, value)
+	// writing the seconds restarts the current second
+	if register == RTC_SECONDS {
+		r.subsecond = 0
+	}
+}
+
+// writing 0x00 and then 0x01 copies the live registers into the latched ones
+func (r *rtc) latch(value uint8) {
+	if r.latch_last == 0x00 && value == 0x01 {
+		r.update()
+		r.latched = r.live
+	}
+	r.latch_last = value
+}
+
+// the footer most emulators (bgb, vba, sameboy, mgba...) add after the ram,
+// every register is a 32 bit little endian value followed by the unix time
+// of the save
+func (r *rtc) save() []uint8 {
+	r.update()
+	footer := make([]uint8, 0, RTC_FOOTER_SIZE)
+	for _, regs := range []*rtcRegisters{&r.live, &r.latched} {
+		for register := uint8(RTC_SECONDS); register <= RTC_DAY_HIGH; register++ {
+			footer = binary.LittleEndian.AppendUint32(footer, uint32(regs.read(register)))
+		}
+	}
+	return binary.LittleEndian.AppendUint64(footer, uint64(r.updated.Unix()))
+}
+
+// restores the footer and catches up with the time that went by since it
+// was saved
+func (r *rtc) load(footer []uint8) {
+	for i, regs := range []*rtcRegisters{&r.live, &r.latched} {
+		*regs = rtcRegisters{}
+		for register := uint8(RTC_SECONDS); register <= RTC_DAY_HIGH; register++ {
+			offset := 4 * (5*i + int(register-RTC_SECONDS))
+			regs.write(register, uint8(binary.LittleEndian.Uint32(footer[offset:])))
+		}
+	}
+	var saved int64
+	if len(footer) == RTC_FOOTER_SIZE {
+		saved = int64(binary.LittleEndian.Uint64(footer[40:]))
+	} else {
+		saved = int64(binary.LittleEndian.Uint32(footer[40:]))
+	}
+	r.updated = time.Unix(saved, 0)
+	r.subsecond = 0
+	r.update()
+}
@@ -0,0 +1,148 @@
+package cartridge
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	SAVE_EXTENSION = ".sav"
+	// the t-cycles in one second of game boy time, the saves are written at
+	// most this often
+	SAVE_INTERVAL = 4194304
+)
+
+var ErrNoBattery = errors.New("cartridge: the cartridge has no battery")
+
+// SavePath is where the save of the rom at rom_path goes by default, the rom
+// with the extension swapped for .sav (pokemon.gb keeps its ram in
+// pokemon.sav)
+func SavePath(rom_path string) string {
+	return strings.TrimSuffix(rom_path, filepath.Ext(rom_path)) + SAVE_EXTENSION
+}
+
+// SaveFile keeps the battery backed memory of a cartridge in a file, it is
+// loaded when it is opened, connected to the cpu it writes whatever changed
+// every SAVE_INTERVAL and Close writes it one last time
+type SaveFile struct {
+	Path    string
+	battery Battery
+	// the memory that is in the file, nothing is written while it matches,
+	// the clock footers change every second so they dont count
+	saved  []uint8
+	cycles uint64
+	timer  bool
+	// why the last write failed, nil once one works
+	err error
+}
+
+// OpenSave loads the save at path into the mapper, a missing file is a new
+// game and not an error, ErrNoBattery is returned for the cartridges that
+// dont keep anything
+func OpenSave(path string, cartridge *Cartridge, mapper Mapper) (*SaveFile, error) {
+	cartridge_type := cartridge.Header.Type
+	// the first bank of a multicart has the header of a game, the menu one
+	// is the one that goes with the mapper
+	if menu, ok := mmm01Type(cartridge.ROM); ok {
+		cartridge_type = menu
+	}
+	features, _ := cartridge_type.Features()
+	battery, ok := mapper.(Battery)
+	if !features.Battery || !ok {
+		return nil, ErrNoBattery
+	}
+	save := &SaveFile{Path: path, battery: battery, timer: features.Timer}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := battery.Load(data); err != nil {
+			return nil, err
+		}
+	}
+	save.saved = bytes.Clone(battery.Memory())
+	return save, nil
+}
+
+// Advance is called by the cpu with the t-cycles that went by, see
+// cpu.Component
+func (s *SaveFile) Advance(cycles uint8) {
+	s.cycles += uint64(cycles)
+	if s.cycles < SAVE_INTERVAL {
+		return
+	}
+	// the cpu cant do anything with the error, it is kept for Err and Close
+	// and the next flush tries again
+	s.Flush()
+}
+
+// Err is why the last write failed, nil if it worked, the periodic flushes
+// cant return it so the frontend has to ask
+func (s *SaveFile) Err() error {
+	return s.err
+}
+
+// Flush writes the save if the memory changed since the last time, the next
+// periodic flush is a SAVE_INTERVAL after it
+func (s *SaveFile) Flush() error {
+	s.cycles = 0
+	if bytes.Equal(s.battery.Memory(), s.saved) {
+		return nil
+	}
+	return s.write()
+}
+
+// Close writes the save on shutdown, the cartridges with a clock always
+// write it so the footer has the time they were turned off (and any change
+// the game made to the clock), a periodic flush that failed is reported here
+// too if nothing was written after it
+func (s *SaveFile) Close() error {
+	if s.timer {
+		s.write()
+	} else {
+		s.Flush()
+	}
+	return s.err
+}
+
+func (s *SaveFile) write() error {
+	s.cycles = 0
+	memory := bytes.Clone(s.battery.Memory())
+	s.err = writeAtomic(s.Path, s.battery.Save())
+	if s.err != nil {
+		return s.err
+	}
+	s.saved = memory
+	return nil
+}
+
+// writes to a file next to path and renames it over path, a crash in the
+// middle leaves the old save untouched instead of half of the new one
+func writeAtomic(path string, data []uint8) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	temporary := file.Name()
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if close_err := file.Close(); err == nil {
+		err = close_err
+	}
+	if err == nil {
+		err = os.Chmod(temporary, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(temporary, path)
+	}
+	if err != nil {
+		os.Remove(temporary)
+	}
+	return err
+}
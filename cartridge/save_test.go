@@ -0,0 +1,267 @@
+package cartridge
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSavePath(t *testing.T) {
+	type test struct {
+		rom      string
+		expected string
+	}
+
+	tests := []test{
+		{rom: "pokemon.gb", expected: "pokemon.sav"},
+		{rom: "/roms/zelda.dx.gbc", expected: "/roms/zelda.dx.sav"},
+		{rom: "roms/tetris", expected: "roms/tetris.sav"},
+	}
+
+	for _, unit_test := range tests {
+		if got := SavePath(unit_test.rom); got != unit_test.expected {
+			t.Errorf("failed : %s expected : %s got : %s", unit_test.rom, unit_test.expected, got)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.rom)
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.sav")
+	cartridge := newBankedROM(4, 0x03, 0x02)
+	mapper, _ := NewMapper(cartridge)
+
+	// a new game doesnt write anything until the ram changes
+	save, err := OpenSave(path, cartridge, mapper)
+	if err != nil {
+		t.Fatalf("failed : open %v", err)
+	}
+	save.Advance(255)
+	if err := save.Close(); err != nil {
+		t.Fatalf("failed : close %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("failed : expected no save for untouched ram got : %v", err)
+	}
+
+	mapper.Write(0x0000, 0x0A)
+	mapper.Write(0xA100, 0x42)
+	// nothing until a second of game boy time went by
+	for range SAVE_INTERVAL/4 - 1 {
+		save.Advance(4)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("failed : expected the save to wait for the interval")
+	}
+	save.Advance(4)
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) != 0x2000 || data[0x100] != 0x42 {
+		t.Fatalf("failed : expected the periodic flush to write the ram %v", err)
+	}
+
+	mapper.Write(0xA101, 0x43)
+	if err := save.Close(); err != nil {
+		t.Fatalf("failed : close %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("failed : expected only the save in the directory got : %d files", len(entries))
+	}
+
+	// the next run gets the ram back
+	mapper, _ = NewMapper(cartridge)
+	if _, err := OpenSave(path, cartridge, mapper); err != nil {
+		t.Fatalf("failed : reopen %v", err)
+	}
+	mapper.Write(0x0000, 0x0A)
+	if mapper.Read(0xA100) != 0x42 || mapper.Read(0xA101) != 0x43 {
+		t.Errorf("failed : expected the ram to be loaded")
+	}
+
+	// a save of the wrong size isnt loaded or overwritten
+	os.WriteFile(path, []uint8{1, 2, 3}, 0o644)
+	var size_err *SaveSizeError
+	if _, err := OpenSave(path, cartridge, mapper); !errors.As(err, &size_err) {
+		t.Errorf("failed : expected a *SaveSizeError got : %v", err)
+	}
+
+	no_battery := newBankedROM(4, 0x02, 0x02)
+	mapper, _ = NewMapper(no_battery)
+	if _, err := OpenSave(path, no_battery, mapper); !errors.Is(err, ErrNoBattery) {
+		t.Errorf("failed : expected ErrNoBattery got : %v", err)
+	}
+
+	// the HuC3 keeps its ram and its clock
+	clock := &testClock{now: time.Unix(1_000_000, 0)}
+	huc3_path := filepath.Join(t.TempDir(), "huc3.sav")
+	huc3_cartridge := newBankedROM(4, 0xFE, 0x03)
+	mapper, _ = NewMapper(huc3_cartridge)
+	mapper.(Clocked).SetClock(clock)
+	save, err = OpenSave(huc3_path, huc3_cartridge, mapper)
+	if err != nil {
+		t.Fatalf("failed : huc3 open %v", err)
+	}
+	mapper.Write(0x0000, HUC3_RAM)
+	mapper.Write(0xA000, 0x77)
+	if err := save.Close(); err != nil {
+		t.Fatalf("failed : huc3 close %v", err)
+	}
+	clock.advance(2 * time.Hour)
+	mapper, _ = NewMapper(huc3_cartridge)
+	mapper.(Clocked).SetClock(clock)
+	if _, err := OpenSave(huc3_path, huc3_cartridge, mapper); err != nil {
+		t.Fatalf("failed : huc3 reopen %v", err)
+	}
+	mapper.Write(0x0000, HUC3_RAM)
+	if value := mapper.Read(0xA000); value != 0x77 {
+		t.Errorf("failed : huc3 expected the ram back got : 0x%02X", value)
+	}
+	if minutes, _ := readHuC3Time(mapper.(*huc3)); minutes != 120 {
+		t.Errorf("failed : huc3 expected the clock to keep going got : %d minutes", minutes)
+	}
+}
+
+// the battery of a multicart is in the header of the menu, the first bank has
+// the one of a game without it
+func TestSaveFileMMM01(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "multicart.sav")
+	cartridge := newTestMMM01()
+	if features, _ := cartridge.Header.Type.Features(); features.Battery {
+		t.Fatalf("failed : expected the first game to have no battery")
+	}
+	data := make([]uint8, 0x8000)
+	data[0x1234] = 0x42
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed : %v", err)
+	}
+
+	mapper, _ := NewMapper(cartridge)
+	save, err := OpenSave(path, cartridge, mapper)
+	if err != nil {
+		t.Fatalf("failed : open %v", err)
+	}
+	mbc := mapper.(*mmm01)
+	if mbc.ram[0x1234] != 0x42 {
+		t.Errorf("failed : expected the save to be loaded")
+	}
+	mbc.ram[0x4321] = 0x43
+	if err := save.Close(); err != nil {
+		t.Fatalf("failed : close %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || data[0x1234] != 0x42 || data[0x4321] != 0x43 {
+		t.Errorf("failed : expected the ram to be written %v", err)
+	}
+}
+
+// the clock footer changes every second but only the ram makes the periodic
+// flush write
+func TestSaveFileRTC(t *testing.T) {
+	clock := &testClock{now: time.Unix(1_000_000, 0)}
+	path := filepath.Join(t.TempDir(), "rtc.sav")
+	cartridge := newBankedROM(4, 0x10, 0x03)
+	mapper, _ := NewMapper(cartridge)
+	mapper.(Clocked).SetClock(clock)
+	save, err := OpenSave(path, cartridge, mapper)
+	if err != nil {
+		t.Fatalf("failed : open %v", err)
+	}
+
+	interval := func() {
+		clock.advance(time.Second)
+		for range SAVE_INTERVAL / 4 {
+			save.Advance(4)
+		}
+	}
+	for range 5 {
+		interval()
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("failed : expected no save while the ram is untouched got : %v", err)
+	}
+
+	mapper.Write(0x0000, 0x0A)
+	mapper.Write(0xA000, 0x42)
+	interval()
+	if data, err := os.ReadFile(path); err != nil || len(data) != 0x8000+RTC_FOOTER_SIZE || data[0] != 0x42 {
+		t.Fatalf("failed : expected the ram and the footer to be written %v", err)
+	}
+	os.Remove(path)
+	for range 5 {
+		interval()
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("failed : expected the clock alone not to write the save got : %v", err)
+	}
+
+	// but shutting down writes the time it happened
+	if err := save.Close(); err != nil {
+		t.Fatalf("failed : close %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) != 0x8000+RTC_FOOTER_SIZE {
+		t.Fatalf("failed : expected close to write the save %v", err)
+	}
+	if saved := binary.LittleEndian.Uint64(data[0x8000+40:]); saved != uint64(clock.now.Unix()) {
+		t.Errorf("failed : expected the footer to have the time of the close got : %d", saved)
+	}
+}
+
+// the periodic flushes cant return their errors, Err and Close have them
+func TestSaveFileError(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "missing")
+	path := filepath.Join(directory, "game.sav")
+	cartridge := newBankedROM(4, 0x03, 0x02)
+	mapper, _ := NewMapper(cartridge)
+	save, err := OpenSave(path, cartridge, mapper)
+	if err != nil {
+		t.Fatalf("failed : open %v", err)
+	}
+
+	mapper.Write(0x0000, 0x0A)
+	mapper.Write(0xA000, 0x42)
+	for range SAVE_INTERVAL / 4 {
+		save.Advance(4)
+	}
+	if save.Err() == nil {
+		t.Fatalf("failed : expected the periodic flush to fail")
+	}
+	// the game puts the ram back so close has nothing new to write, the
+	// failure is still reported
+	mapper.Write(0xA000, 0x00)
+	if err := save.Close(); err == nil {
+		t.Errorf("failed : expected close to report the failed flush")
+	}
+
+	// and it goes away once a write works
+	os.Mkdir(directory, 0o755)
+	mapper.Write(0xA000, 0x43)
+	if err := save.Close(); err != nil || save.Err() != nil {
+		t.Errorf("failed : expected the save to be written %v", err)
+	}
+}
+
+func TestWriteAtomic(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "game.sav")
+	if err := writeAtomic(path, []uint8{1}); err != nil {
+		t.Fatalf("failed : %v", err)
+	}
+	if err := writeAtomic(path, []uint8{2, 3}); err != nil {
+		t.Fatalf("failed : %v", err)
+	}
+	if data, _ := os.ReadFile(path); len(data) != 2 || data[0] != 2 {
+		t.Errorf("failed : expected the second write got : %v", data)
+	}
+
+	// a directory that doesnt exist fails without leaving anything behind
+	if err := writeAtomic(filepath.Join(directory, "missing", "game.sav"), []uint8{1}); err == nil {
+		t.Errorf("failed : expected an error for a missing directory")
+	}
+	if entries, _ := os.ReadDir(directory); len(entries) != 1 {
+		t.Errorf("failed : expected only the save in the directory got : %d files", len(entries))
+	}
+}
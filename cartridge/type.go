@@ -0,0 +1,110 @@
+package cartridge
+
+import "fmt"
+
+// the cartridge type byte at 0x0147, it says which memory bank controller
+// the cartridge has and what else is in it
+type Type uint8
+
+// the memory bank controllers, the chip that lets the cpu see more than 32kb
+// of rom and the external ram
+type MBC uint8
+
+const (
+	NoMBC MBC = iota
+	MBC1
+	MBC2
+	MBC3
+	MBC5
+	MBC6
+	MBC7
+	MMM01
+	HuC1
+	HuC3
+	PocketCamera
+	TAMA5
+)
+
+func (mbc MBC) String() string {
+	return [...]string{"ROM", "MBC1", "MBC2", "MBC3", "MBC5", "MBC6", "MBC7", "MMM01", "HuC1", "HuC3", "POCKET CAMERA", "BANDAI TAMA5"}[mbc]
+}
+
+type Features struct {
+	MBC MBC
+	RAM bool
+	// keeps the ram (and the clock) alive when the game boy is off
+	Battery bool
+	// the real time clock of the MBC3 and the HuC3
+	Timer  bool
+	Rumble bool
+	// the MBC7 accelerometer
+	Sensor bool
+}
+
+// https://gbdev.io/pandocs/The_Cartridge_Header.html#0147--cartridge-type
+var types = map[Type]Features{
+	0x00: {MBC: NoMBC},
+	0x01: {MBC: MBC1},
+	0x02: {MBC: MBC1, RAM: true},
+	0x03: {MBC: MBC1, RAM: true, Battery: true},
+	0x05: {MBC: MBC2},
+	0x06: {MBC: MBC2, Battery: true},
+	0x08: {MBC: NoMBC, RAM: true},
+	0x09: {MBC: NoMBC, RAM: true, Battery: true},
+	0x0B: {MBC: MMM01},
+	0x0C: {MBC: MMM01, RAM: true},
+	0x0D: {MBC: MMM01, RAM: true, Battery: true},
+	0x0F: {MBC: MBC3, Timer: true, Battery: true},
+	0x10: {MBC: MBC3, Timer: true, RAM: true, Battery: true},
+	0x11: {MBC: MBC3},
+	0x12: {MBC: MBC3, RAM: true},
+	0x13: {MBC: MBC3, RAM: true, Battery: true},
+	0x19: {MBC: MBC5},
+	0x1A: {MBC: MBC5, RAM: true},
+	0x1B: {MBC: MBC5, RAM: true, Battery: true},
+	0x1C: {MBC: MBC5, Rumble: true},
+	0x1D: {MBC: MBC5, Rumble: true, RAM: true},
+	0x1E: {MBC: MBC5, Rumble: true, RAM: true, Battery: true},
+	0x20: {MBC: MBC6, RAM: true, Battery: true},
+	0x22: {MBC: MBC7, Sensor: true, Rumble: true, RAM: true, Battery: true},
+	0xFC: {MBC: PocketCamera},
+	0xFD: {MBC: TAMA5},
+	// the HuC3 always has a battery for its clock
+	0xFE: {MBC: HuC3, Timer: true, RAM: true, Battery: true},
+	0xFF: {MBC: HuC1, RAM: true, Battery: true},
+}
+
+// Features returns what the type byte says is in the cartridge, ok is false
+// for the values that dont mean anything
+func (t Type) Features() (features Features, ok bool) {
+	features, ok = types[t]
+	return features, ok
+}
+
+// the name the pandocs use, MBC1+RAM+BATTERY
+func (t Type) String() string {
+	features, ok := t.Features()
+	if !ok {
+		return fmt.Sprintf("UNKNOWN 0x%02X", uint8(t))
+	}
+	name := features.MBC.String()
+	if features.MBC == NoMBC && !features.RAM {
+		return "ROM ONLY"
+	}
+	if features.Timer {
+		name += "+TIMER"
+	}
+	if features.Sensor {
+		name += "+SENSOR"
+	}
+	if features.Rumble {
+		name += "+RUMBLE"
+	}
+	if features.RAM {
+		name += "+RAM"
+	}
+	if features.Battery {
+		name += "+BATTERY"
+	}
+	return name
+}
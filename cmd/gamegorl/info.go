@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/chilepikmin/gamegorl/cartridge"
+)
+
+// prints everything the header says about the rom plus the hashes to look it
+// up in a dump database, bad checksums are reported but are not an error
+func info(out io.Writer, path string) error {
+	cart, err := cartridge.Load(path)
+	if err != nil {
+		return err
+	}
+	header := &cart.Header
+
+	destination := "japan"
+	if header.Destination != 0x00 {
+		destination = "overseas"
+	}
+	sgb := "no"
+	if header.SGB() {
+		sgb = "yes"
+	}
+
+	fmt.Fprintf(out, "file:             %s\n", path)
+	fmt.Fprintf(out, "title:            %s\n", header.Title)
+	fmt.Fprintf(out, "manufacturer:     %s\n", header.Manufacturer)
+	fmt.Fprintf(out, "cgb:              %s (0x%02X)\n", header.CGB(), header.CGBFlag)
+	fmt.Fprintf(out, "sgb:              %s (0x%02X)\n", sgb, header.SGBFlag)
+	fmt.Fprintf(out, "type:             %s (0x%02X)\n", header.Type, uint8(header.Type))
+	fmt.Fprintf(out, "rom size:         %d KiB, %d banks (0x%02X)\n", header.ROMBytes()/1024, header.ROMBanks(), header.ROMSize)
+	if header.ROMBytes() != len(cart.ROM) {
+		fmt.Fprintf(out, "                  the file is %d bytes\n", len(cart.ROM))
+	}
+	fmt.Fprintf(out, "ram size:         %d KiB (0x%02X)\n", header.RAMBytes()/1024, header.RAMSize)
+	fmt.Fprintf(out, "destination:      %s (0x%02X)\n", destination, header.Destination)
+	if header.OldLicensee == cartridge.USE_NEW_LICENSEE {
+		fmt.Fprintf(out, "licensee:         %s (%q)\n", header.Licensee(), header.NewLicensee)
+	} else {
+		fmt.Fprintf(out, "licensee:         %s (0x%02X)\n", header.Licensee(), header.OldLicensee)
+	}
+	fmt.Fprintf(out, "version:          %d\n", header.Version)
+	fmt.Fprintf(out, "header checksum:  0x%02X %s\n", header.HeaderChecksum, checksumStatus(cart.CheckHeader()))
+	fmt.Fprintf(out, "global checksum:  0x%04X %s\n", header.GlobalChecksum, checksumStatus(cart.CheckGlobal()))
+	fmt.Fprintf(out, "crc32:            %08x\n", cart.CRC32())
+	fmt.Fprintf(out, "sha1:             %x\n", cart.SHA1())
+	return nil
+}
+
+func checksumStatus(err error) string {
+	if err != nil {
+		return fmt.Sprintf("bad (%v)", err)
+	}
+	return "ok"
+}
@@ -1,7 +1,34 @@
 package main
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage:
+	gamegorl info <rom>    prints the cartridge header and the hashes of the rom`
 
 func main() {
-	fmt.Println("hello world")
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "info":
+		{
+			if len(args) != 2 {
+				return errors.New(usage)
+			}
+			return info(out, args[1])
+		}
+	}
+	return fmt.Errorf("unknown command %q\n%s", args[0], usage)
 }
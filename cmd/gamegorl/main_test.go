@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInfo(t *testing.T) {
+	rom := make([]uint8, 0x8000)
+	copy(rom[0x134:], "TEST")
+	rom[0x147] = 0x13
+	rom[0x149] = 0x03
+	rom[0x14B] = 0x01
+	rom[0x14D] = 0x90
+	path := filepath.Join(t.TempDir(), "test.gb")
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"info", path}, &out); err != nil {
+		t.Fatalf("failed : info %v", err)
+	}
+	expected := []string{
+		"title:            TEST\n",
+		"type:             MBC3+RAM+BATTERY (0x13)\n",
+		"rom size:         32 KiB, 2 banks (0x00)\n",
+		"ram size:         32 KiB (0x03)\n",
+		"licensee:         Nintendo (0x01)\n",
+		"header checksum:  0x90 ok\n",
+		"global checksum:  0x0000 bad",
+		"crc32:            ",
+		"sha1:             ",
+	}
+	for _, line := range expected {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("failed : info expected %q in :\n%s", line, out.String())
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	var out bytes.Buffer
+	for _, args := range [][]string{{}, {"info"}, {"play", "rom.gb"}, {"info", filepath.Join(t.TempDir(), "missing.gb")}} {
+		if err := run(args, &out); err == nil {
+			t.Errorf("failed : %v expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package cpu
+
+func (cpu *CPU) getWideByTarget(target ArithmeticTarget) uint16 {
+	var value uint16
+	switch target {
+	case BC:
+		{
+			value = cpu.regs.getBC()
+		}
+	case DE:
+		{
+			value = cpu.regs.getDE()
+		}
+	case HL:
+		{
+			value = cpu.regs.getHL()
+		}
+	case SP:
+		{
+			value = cpu.regs.sp
+		}
+	case AF:
+		{
+			value = cpu.regs.getAF()
+		}
+	case D16:
+		{
+			value = cpu.imm
+		}
+	}
+	return value
+}
+
+func (cpu *CPU) setWideByTarget(target ArithmeticTarget, value uint16) {
+	switch target {
+	case BC:
+		{
+			cpu.regs.setBC(value)
+		}
+	case DE:
+		{
+			cpu.regs.setDE(value)
+		}
+	case HL:
+		{
+			cpu.regs.setHL(value)
+		}
+	case SP:
+		{
+			cpu.regs.sp = value
+		}
+	case AF:
+		{
+			cpu.regs.setAF(value)
+		}
+	}
+}
+
+// LD rr,d16 LD SP,HL and LD (a16),SP, the last one stores the low byte first
+func (cpu *CPU) load16(target, source ArithmeticTarget) {
+	value := cpu.getWideByTarget(source)
+	if target == A16I {
+		cpu.write(cpu.imm, uint8(value&0xFF))
+		cpu.write(cpu.imm+1, uint8(value>>8))
+		return
+	}
+	if target == SP && source == HL {
+		// LD SP,HL goes through the 16 bit incrementer which takes an
+		// m-cycle
+		cpu.tick()
+	}
+	cpu.setWideByTarget(target, value)
+}
+
+// adds the target pair to hl, the zero flag is left alone and the half carry
+// comes from bit 11 instead of bit 3 because the alu does it in two halves
+func (cpu *CPU) addHL(target ArithmeticTarget) {
+	hl := cpu.regs.getHL()
+	value := cpu.getWideByTarget(target)
+	result := uint32(hl) + uint32(value)
+	cpu.regs.f.substract = false
+	cpu.regs.f.half_carry = (hl&0xFFF)+(value&0xFFF) > 0xFFF
+	cpu.regs.f.carry = result > 0xFFFF
+	cpu.regs.setHL(uint16(result))
+	cpu.tick()
+}
+
+// 16 bit inc and dec dont touch any flag but take an extra m-cycle
+func (cpu *CPU) inc16(target ArithmeticTarget) {
+	cpu.setWideByTarget(target, cpu.getWideByTarget(target)+1)
+	cpu.tick()
+}
+
+func (cpu *CPU) dec16(target ArithmeticTarget) {
+	cpu.setWideByTarget(target, cpu.getWideByTarget(target)-1)
+	cpu.tick()
+}
+
+// sp plus the signed immediate (r8), the flags are weird here, zero and
+// substract are always cleared and half carry and carry come from adding the
+// low byte of sp to the immediate as if both were unsigned (bit 3 and bit 7)
+func (cpu *CPU) spPlusImmediate() uint16 {
+	sp := cpu.regs.sp
+	offset := uint16(int8(uint8(cpu.imm)))
+	cpu.regs.f.zero = false
+	cpu.regs.f.substract = false
+	cpu.regs.f.half_carry = (sp&0xF)+(offset&0xF) > 0xF
+	cpu.regs.f.carry = (sp&0xFF)+(offset&0xFF) > 0xFF
+	return sp + offset
+}
+
+// ADD SP,r8, 2 internal m-cycles
+func (cpu *CPU) addSP() {
+	cpu.regs.sp = cpu.spPlusImmediate()
+	cpu.tick()
+	cpu.tick()
+}
+
+// LD HL,SP+r8, 1 internal m-cycle
+func (cpu *CPU) loadHLSP() {
+	cpu.regs.setHL(cpu.spPlusImmediate())
+	cpu.tick()
+}
@@ -0,0 +1,135 @@
+package cpu
+
+import "testing"
+
+func TestALU16(t *testing.T) {
+	type test struct {
+		title    string
+		program  []uint8
+		regs     Registers
+		expected Registers
+		written  map[uint16]uint8
+		cycles   uint8
+	}
+
+	tests := []test{
+		{
+			title:    "ld de,d16",
+			program:  []uint8{0x11, 0x34, 0x12},
+			expected: Registers{d: 0x12, e: 0x34, pc: 3},
+			cycles:   12,
+		},
+		{
+			title:    "ld sp,d16",
+			program:  []uint8{0x31, 0xFE, 0xFF},
+			expected: Registers{sp: 0xFFFE, pc: 3},
+			cycles:   12,
+		},
+		{
+			title:    "ld (a16),sp",
+			program:  []uint8{0x08, 0x00, 0xC0},
+			regs:     Registers{sp: 0xBEEF},
+			expected: Registers{sp: 0xBEEF, pc: 3},
+			written:  map[uint16]uint8{0xC000: 0xEF, 0xC001: 0xBE},
+			cycles:   20,
+		},
+		{
+			title:    "ld sp,hl",
+			program:  []uint8{0xF9},
+			regs:     Registers{h: 0xD0, l: 0x10},
+			expected: Registers{h: 0xD0, l: 0x10, sp: 0xD010, pc: 1},
+			cycles:   8,
+		},
+		{
+			title:    "inc bc wraps without flags",
+			program:  []uint8{0x03},
+			regs:     Registers{b: 0xFF, c: 0xFF},
+			expected: Registers{pc: 1},
+			cycles:   8,
+		},
+		{
+			title:    "dec sp",
+			program:  []uint8{0x3B},
+			regs:     Registers{f: FlagsRegister{zero: true}},
+			expected: Registers{sp: 0xFFFF, pc: 1, f: FlagsRegister{zero: true}},
+			cycles:   8,
+		},
+		{
+			// 0000111111111111
+			// 0000000000000001
+			title:    "add hl,bc half carry from bit 11",
+			program:  []uint8{0x09},
+			regs:     Registers{h: 0x0F, l: 0xFF, c: 0x01, f: FlagsRegister{zero: true, substract: true}},
+			expected: Registers{h: 0x10, l: 0x00, c: 0x01, pc: 1, f: FlagsRegister{zero: true, half_carry: true}},
+			cycles:   8,
+		},
+		{
+			title:    "add hl,de carry",
+			program:  []uint8{0x19},
+			regs:     Registers{h: 0x80, d: 0x80},
+			expected: Registers{d: 0x80, pc: 1, f: FlagsRegister{carry: true}},
+			cycles:   8,
+		},
+		{
+			title:    "add hl,hl",
+			program:  []uint8{0x29},
+			regs:     Registers{h: 0x8A, l: 0x23},
+			expected: Registers{h: 0x14, l: 0x46, pc: 1, f: FlagsRegister{half_carry: true, carry: true}},
+			cycles:   8,
+		},
+		{
+			title:    "add hl,sp",
+			program:  []uint8{0x39},
+			regs:     Registers{h: 0x00, l: 0x01, sp: 0x0002},
+			expected: Registers{l: 0x03, sp: 0x0002, pc: 1},
+			cycles:   8,
+		},
+		{
+			// the flags come from the low byte, 0xFF + 0x01
+			title:    "add sp,r8",
+			program:  []uint8{0xE8, 0x01},
+			regs:     Registers{sp: 0x00FF, f: FlagsRegister{zero: true, substract: true}},
+			expected: Registers{sp: 0x0100, pc: 2, f: FlagsRegister{half_carry: true, carry: true}},
+			cycles:   16,
+		},
+		{
+			// -1 is 0xFF so adding it to 0x00 doesnt carry anything
+			title:    "add sp,r8 negative",
+			program:  []uint8{0xE8, 0xFF},
+			regs:     Registers{sp: 0xD000},
+			expected: Registers{sp: 0xCFFF, pc: 2},
+			cycles:   16,
+		},
+		{
+			title:    "ld hl,sp+r8",
+			program:  []uint8{0xF8, 0xFE},
+			regs:     Registers{sp: 0xFFF8},
+			expected: Registers{h: 0xFF, l: 0xF6, sp: 0xFFF8, pc: 2, f: FlagsRegister{half_carry: true, carry: true}},
+			cycles:   12,
+		},
+	}
+
+	for _, unit_test := range tests {
+		cpu, bus := newTestCPU(unit_test.program...)
+		cpu.regs = unit_test.regs
+		cycles := cpu.Step()
+		success := true
+		if cpu.regs != unit_test.expected {
+			t.Errorf("failed : %s expected : %v got : %v", unit_test.title, &unit_test.expected, &cpu.regs)
+			success = false
+		}
+		for address, value := range unit_test.written {
+			if bus[address] != value {
+				t.Errorf("failed : %s expected (0x%04X) : 0x%02X got : 0x%02X", unit_test.title, address, value, bus[address])
+				success = false
+			}
+		}
+		if cycles != unit_test.cycles {
+			t.Errorf("failed : %s expected : %d cycles got : %d", unit_test.title, unit_test.cycles, cycles)
+			success = false
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
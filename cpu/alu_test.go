@@ -0,0 +1,101 @@
+package cpu
+
+import "testing"
+
+// the opcodes checked against the reference, (hl) and d8 are left out because
+// TestOperandSources already checks they behave like the registers
+func isVerifiedOpcode(op opcode) bool {
+	switch op.instruction {
+	case RLA, RLCA, RRA, RRCA, CPL, CCF, SCF:
+		{
+			return true
+		}
+	case ADD, ADC, SUB, SBC, AND, OR, XOR, CP, INC, DEC, RL, RLC, RR, RRC, SLA, SRA, SRL, SWAP, BIT, RESET, SET:
+		{
+			return isRegister(op.target)
+		}
+	}
+	return false
+}
+
+// every alu, rotate, shift and bit opcode is run with every value of a, every
+// value of its operand and every combination of the 4 flags and compared to
+// referenceCPU, only the first mismatch of each opcode is reported so a
+// broken handler doesnt flood the output
+func TestALUAgainstReference(t *testing.T) {
+	if testing.Short() {
+		t.Skip("exhaustive alu verification")
+	}
+
+	type test struct {
+		name string
+		op   operation
+	}
+
+	tests := []test{}
+	for _, op := range opcodes {
+		if isVerifiedOpcode(op) {
+			tests = append(tests, test{name: op.mnemonic, op: op.operation})
+		}
+	}
+	for _, op := range cbOpcodes {
+		if isVerifiedOpcode(op) {
+			tests = append(tests, test{name: op.mnemonic, op: op.operation})
+		}
+	}
+
+	cpu, _ := newTestCPU()
+	for _, unit_test := range tests {
+		op := unit_test.op
+		// with A as the operand (or no operand at all, RLA CPL...) both
+		// values are the same byte
+		values := 256
+		if op.target == A {
+			values = 1
+		}
+	search:
+		for a := range 256 {
+			for value := range values {
+				if op.target == A {
+					value = a
+				}
+				for flags := range 16 {
+					reference := referenceCPU{a: uint8(a), f: uint8(flags << 4)}
+					expected_value := reference.run(op, uint8(value))
+
+					cpu.regs = Registers{}
+					cpu.regs.a = reg(a)
+					cpu.regs.f = toFlagReg(uint8(flags << 4))
+					if op.target != A {
+						cpu.setRegisterByTarget(op.target, reg(value))
+					}
+					cpu.execute(op)
+
+					got_value := uint8(cpu.getRegisterValueByTarget(op.target))
+					if op.target == A {
+						// the operand and a are the same register
+						if modifiesOperand(op.instruction) {
+							reference.a = expected_value
+						}
+						expected_value = reference.a
+					}
+					if uint8(cpu.regs.a) != reference.a || cpu.regs.f.toBit() != reference.f || got_value != expected_value {
+						t.Errorf("failed : %s a : 0x%02X value : 0x%02X f : 0x%02X expected a : 0x%02X f : 0x%02X value : 0x%02X got a : 0x%02X f : 0x%02X value : 0x%02X",
+							unit_test.name, a, value, flags<<4, reference.a, reference.f, expected_value, cpu.regs.a, cpu.regs.f.toBit(), got_value)
+						break search
+					}
+				}
+			}
+		}
+	}
+}
+
+func modifiesOperand(instruction Instruction) bool {
+	switch instruction {
+	case INC, DEC, RL, RLC, RR, RRC, SLA, SRA, SRL, SWAP, RESET, SET:
+		{
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package cpu
+
+const (
+	// where the boot rom leaves pc, the entry point in the cartridge header
+	ENTRY_POINT = 0x0100
+	// and sp, the top of HRAM
+	BOOT_STACK = 0xFFFE
+)
+
+// PostBootRegisters returns the registers with the pairs the boot rom leaves
+// behind and pc and sp where it leaves them, the lower nibble of f doesnt
+// exist so it is dropped
+func PostBootRegisters(af, bc, de, hl uint16) Registers {
+	regs := Registers{pc: ENTRY_POINT, sp: BOOT_STACK}
+	regs.setAF(af)
+	regs.setBC(bc)
+	regs.setDE(de)
+	regs.setHL(hl)
+	return regs
+}
+
+func (r Registers) AF() uint16 {
+	return r.getAF()
+}
+
+func (r Registers) BC() uint16 {
+	return r.getBC()
+}
+
+func (r Registers) DE() uint16 {
+	return r.getDE()
+}
+
+func (r Registers) HL() uint16 {
+	return r.getHL()
+}
+
+func (r Registers) SP() uint16 {
+	return r.sp
+}
+
+func (r Registers) PC() uint16 {
+	return r.pc
+}
+
+// Registers returns a copy of the registers
+func (cpu *CPU) Registers() Registers {
+	return cpu.regs
+}
+
+// SetRegisters replaces every register, it is how the cpu starts without a
+// boot rom
+func (cpu *CPU) SetRegisters(regs Registers) {
+	cpu.regs = regs
+}
@@ -0,0 +1,35 @@
+package cpu
+
+import "testing"
+
+func TestPostBootRegisters(t *testing.T) {
+	type test struct {
+		title          string
+		af, bc, de, hl uint16
+		expected_af    uint16
+	}
+
+	tests := []test{
+		{title: "dmg", af: 0x01B0, bc: 0x0013, de: 0x00D8, hl: 0x014D, expected_af: 0x01B0},
+		{title: "cgb", af: 0x1180, bc: 0x0000, de: 0xFF56, hl: 0x000D, expected_af: 0x1180},
+		{title: "lower nibble of f", af: 0x01BF, bc: 0x0013, de: 0x00D8, hl: 0x014D, expected_af: 0x01B0},
+	}
+
+	for _, unit_test := range tests {
+		cpu, bus := newTestCPU()
+		cpu.SetRegisters(PostBootRegisters(unit_test.af, unit_test.bc, unit_test.de, unit_test.hl))
+		regs := cpu.Registers()
+		if regs.AF() != unit_test.expected_af || regs.BC() != unit_test.bc || regs.DE() != unit_test.de || regs.HL() != unit_test.hl || regs.SP() != BOOT_STACK || regs.PC() != ENTRY_POINT {
+			t.Errorf("failed : %s unexpected registers %v", unit_test.title, regs.String())
+			continue
+		}
+		// the first opcode comes from the entry point
+		bus[ENTRY_POINT] = 0x3C
+		cpu.Step()
+		if regs := cpu.Registers(); regs.PC() != ENTRY_POINT+1 || regs.AF()>>8 != unit_test.af>>8+1 {
+			t.Errorf("failed : %s expected INC A at the entry point", unit_test.title)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+}
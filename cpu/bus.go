@@ -0,0 +1,15 @@
+package cpu
+
+// the cpu doesnt own any memory, everything it can see (rom, ram, io
+// registers...) is reached through the bus by its 16 bit address
+type Bus interface {
+	Read(address uint16) uint8
+	Write(address uint16, value uint8)
+}
+
+// a bus with a cartridge that switches banks can tell which bank an address
+// is mapped to, the cpu only uses it to say where things went wrong
+type BankedBus interface {
+	Bus
+	Bank(address uint16) uint16
+}
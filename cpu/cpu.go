@@ -5,8 +5,166 @@ type Instruction uint8
 
 type CPU struct {
 	regs Registers
+	bus  Bus
+	// the immediate operand (d8, d16, a8, a16 or r8) of the instruction
+	// being executed, execute copies it from the operation
+	imm uint16
+	// (hl) and d8 operands are copied here so the handlers can work with a
+	// *reg like they do with the real registers, if mem_dirty is set the
+	// value came from (hl) and gets written back to memory once the
+	// instruction is done, an immediate has nowhere to go back to so only the
+	// flags are kept
+	mem       reg
+	mem_dirty bool
+
+	// t-cycles since the cpu was turned on and the components that have to
+	// run alongside it, see tick
+	cycles     uint64
+	components []Component
+
+	// interrupt master enable, EI doesnt set it straight away but after the
+	// instruction that follows it so ime_scheduled keeps track of that
+	ime           bool
+	ime_scheduled bool
+	// IE (0xFFFF) and IF (0xFF0F)
+	interrupt_enable uint8
+	interrupt_flag   uint8
+
+	// low power modes, HALT waits for an interrupt and STOP for a button
+	halted  bool
+	stopped bool
+	// HALT with ime off and an interrupt already pending doesnt halt, instead
+	// the next opcode fetch forgets to increment pc
+	halt_bug bool
+	// set by an illegal opcode, the cpu doesnt do anything else until Reset
+	locked error
+}
+
+func New(bus Bus) *CPU {
+	return &CPU{bus: bus}
+}
+
+// Core is what the rest of the emulator needs from a cpu, CPU runs a whole
+// instruction at a time and MicroCPU splits them into m-cycles
+type Core interface {
+	Step() uint8
+	RequestInterrupt(interrupt Interrupt)
+	Connect(components ...Component)
+	Cycles() uint64
+	Err() error
+	Reset()
+	Registers() Registers
+	SetRegisters(regs Registers)
 }
 
 func (cpu *CPU) setZeroFlag(value bool) {
 	cpu.regs.f.zero = value
 }
+
+// every bus access takes one m-cycle
+func (cpu *CPU) read(address uint16) uint8 {
+	cpu.tick()
+	switch address {
+	case IE_ADDRESS:
+		{
+			return cpu.interrupt_enable
+		}
+	case IF_ADDRESS:
+		{
+			// only the lower 5 bits exist, the rest read as ones
+			return cpu.interrupt_flag | 0xE0
+		}
+	}
+	return cpu.bus.Read(address)
+}
+
+func (cpu *CPU) write(address uint16, value uint8) {
+	cpu.tick()
+	switch address {
+	case IE_ADDRESS:
+		{
+			cpu.interrupt_enable = value
+			return
+		}
+	case IF_ADDRESS:
+		{
+			cpu.interrupt_flag = value & 0x1F
+			return
+		}
+	}
+	cpu.bus.Write(address, value)
+}
+
+// reads the byte pointed by pc and moves pc to the next one
+func (cpu *CPU) fetch() uint8 {
+	value := cpu.read(cpu.regs.pc)
+	if cpu.halt_bug {
+		cpu.halt_bug = false
+	} else {
+		cpu.regs.pc++
+	}
+	return value
+}
+
+// Step fetches the opcode at pc, decodes it, runs it and returns the
+// amount of t-cycles it took, the connected components were already advanced
+// by the same amount by the time it returns, after an illegal opcode it only
+// spends one m-cycle per call, see Err
+func (cpu *CPU) Step() uint8 {
+	start := cpu.cycles
+	if cpu.stopped || cpu.locked != nil {
+		cpu.tick()
+		return uint8(cpu.cycles - start)
+	}
+	if cpu.halted {
+		if cpu.pendingInterrupts() == 0 {
+			cpu.tick()
+			return uint8(cpu.cycles - start)
+		}
+		// any pending interrupt wakes the cpu up, even with ime off, in that
+		// case it just continues after the HALT without servicing it
+		cpu.halted = false
+	}
+	if cpu.ime && cpu.pendingInterrupts() != 0 {
+		cpu.serviceInterrupt()
+		return uint8(cpu.cycles - start)
+	}
+	// EI only takes effect once the instruction after it is done
+	enable_ime := cpu.ime_scheduled
+
+	address := cpu.regs.pc
+	code := cpu.fetch()
+	op := opcodes[code]
+	if op.instruction == ILLEGAL {
+		cpu.lock(code, address)
+		return uint8(cpu.cycles - start)
+	}
+
+	operation := op.operation
+	if op.instruction == PREFIX {
+		// 0xCB means the real opcode is the next byte and it lives in
+		// the cb table, those never have immediate operands
+		operation = cbOpcodes[cpu.fetch()].operation
+	} else {
+		// the operands are little endian so the low byte comes first
+		switch op.length {
+		case 2:
+			{
+				operation.immediate = uint16(cpu.fetch())
+			}
+		case 3:
+			{
+				low := uint16(cpu.fetch())
+				high := uint16(cpu.fetch())
+				operation.immediate = high<<8 | low
+			}
+		}
+	}
+
+	cpu.execute(operation)
+	if enable_ime && cpu.ime_scheduled {
+		cpu.ime = true
+		cpu.ime_scheduled = false
+	}
+	return uint8(cpu.cycles - start)
+}
@@ -555,7 +555,7 @@ func TestCPUAdd(t *testing.T) {
 
 	for _, unit_test := range tests {
 		// result :=
-		unit_test.cpu.execute(ADD, unit_test.target)
+		unit_test.cpu.execute(operation{instruction: ADD, target: unit_test.target})
 		success := true
 		if unit_test.cpu.regs.a != unit_test.sum_result {
 			t.Errorf(
@@ -607,8 +607,8 @@ func TestCPUADC(t *testing.T) {
 
 	tests := []test{
 		{
-			title:      "overflow and then adding the carry",
-			sum_result: 2,
+			title:      "overflow sets the carry",
+			sum_result: 1,
 			flags_result: FlagsRegister{
 				zero:       false,
 				substract:  false,
@@ -626,10 +626,10 @@ func TestCPUADC(t *testing.T) {
 			target: C,
 		},
 		{
-			title:      "overflow and then adding the carry",
-			sum_result: 1,
+			title:      "overflow into zero",
+			sum_result: 0,
 			flags_result: FlagsRegister{
-				zero:       false,
+				zero:       true,
 				substract:  false,
 				half_carry: true,
 				carry:      true,
@@ -644,11 +644,29 @@ func TestCPUADC(t *testing.T) {
 			},
 			target: C,
 		},
+		{
+			title:      "adding the carry",
+			sum_result: 4,
+			flags_result: FlagsRegister{
+				zero:       false,
+				substract:  false,
+				half_carry: false,
+				carry:      false,
+			},
+			cpu: CPU{
+				regs: Registers{
+					a: reg(1),
+					c: reg(2),
+					f: FlagsRegister{carry: true},
+				},
+			},
+			target: C,
+		},
 	}
 
 	for _, unit_test := range tests {
 		// t :=
-		unit_test.cpu.execute(ADC, unit_test.target)
+		unit_test.cpu.execute(operation{instruction: ADC, target: unit_test.target})
 		success := true
 		if unit_test.cpu.regs.a != unit_test.sum_result {
 			t.Errorf(
@@ -707,7 +725,7 @@ func TestCPUSUB(t *testing.T) {
 			flags_result: FlagsRegister{
 				zero:       false,
 				substract:  true,
-				half_carry: true,
+				half_carry: false,
 				carry:      false,
 			},
 			cpu: CPU{
@@ -723,11 +741,11 @@ func TestCPUSUB(t *testing.T) {
 		{
 			instruction: SBC,
 			title:       "simple substraction with carry",
-			sub_result:  250,
+			sub_result:  251,
 			flags_result: FlagsRegister{
 				zero:       false,
 				substract:  true,
-				half_carry: false,
+				half_carry: true,
 				carry:      true,
 			},
 			cpu: CPU{
@@ -744,7 +762,7 @@ func TestCPUSUB(t *testing.T) {
 
 	for _, unit_test := range tests {
 		// t :=
-		unit_test.cpu.execute(unit_test.instruction, unit_test.target)
+		unit_test.cpu.execute(operation{instruction: unit_test.instruction, target: unit_test.target})
 		success := true
 		if unit_test.cpu.regs.a != unit_test.sub_result {
 			t.Errorf(
@@ -819,7 +837,7 @@ func TestBitWise(t *testing.T) {
 			flags_result: FlagsRegister{
 				zero:       false,
 				substract:  false,
-				half_carry: true,
+				half_carry: false,
 				carry:      false,
 			},
 			cpu: CPU{
@@ -841,7 +859,7 @@ func TestBitWise(t *testing.T) {
 			flags_result: FlagsRegister{
 				zero:       false,
 				substract:  false,
-				half_carry: true,
+				half_carry: false,
 				carry:      false,
 			},
 			cpu: CPU{
@@ -864,7 +882,7 @@ func TestBitWise(t *testing.T) {
 			flags_result: FlagsRegister{
 				zero:       true,
 				substract:  true,
-				half_carry: true,
+				half_carry: false,
 				carry:      false,
 			},
 			cpu: CPU{
@@ -907,7 +925,7 @@ func TestBitWise(t *testing.T) {
 
 	for _, unit_test := range tests {
 		// t :=
-		unit_test.cpu.execute(unit_test.instruction, unit_test.target)
+		unit_test.cpu.execute(operation{instruction: unit_test.instruction, target: unit_test.target})
 		success := true
 		if unit_test.cpu.regs.a != unit_test.result {
 			t.Errorf(
@@ -1068,7 +1086,7 @@ func TestUtilsInstructions(t *testing.T) {
 
 	for _, unit_test := range tests {
 		// t :=
-		cpu.execute(unit_test.instruction, unit_test.target)
+		cpu.execute(operation{instruction: unit_test.instruction, target: unit_test.target})
 		success := true
 		if *unit_test.result != unit_test.expected {
 			t.Errorf(
@@ -1406,7 +1424,7 @@ func TestRegisterRotation(t *testing.T) {
 			// a test based on that
 			// c:0
 			// a:10010101
-			// a:00101011
+			// a:00101010
 			// c:1
 			regs: Registers{
 				a: 0b10010101,
@@ -1416,7 +1434,7 @@ func TestRegisterRotation(t *testing.T) {
 			},
 			instruction: SLA,
 			title:       "simple sla",
-			expected:    0b00101011,
+			expected:    0b00101010,
 			result:      &cpu.regs.a,
 			flags_result: FlagsRegister{
 				carry: true,
@@ -1448,7 +1466,7 @@ func TestRegisterRotation(t *testing.T) {
 	for _, unit_test := range tests {
 		// t :=
 		unit_test.updateRegisters(&cpu)
-		cpu.execute(unit_test.instruction, unit_test.target)
+		cpu.execute(operation{instruction: unit_test.instruction, target: unit_test.target})
 		success := true
 		if *unit_test.result != unit_test.expected {
 			t.Errorf(
@@ -1525,7 +1543,7 @@ func TestBitManipulation(t *testing.T) {
 	for _, unit_test := range tests {
 		// t :=
 		unit_test.updateRegisters(&cpu)
-		cpu.execute(unit_test.instruction, unit_test.target)
+		cpu.execute(operation{instruction: unit_test.instruction, target: unit_test.target})
 		success := true
 		if *unit_test.result != unit_test.expected {
 			t.Errorf(
@@ -1589,7 +1607,7 @@ func TestBitBit(t *testing.T) {
 		// t :=
 		for b := range 8 {
 			t.Logf("reg a: %08b", cpu.regs.a)
-			cpu.execute(unit_test.instruction, unit_test.target, uint8(b))
+			cpu.execute(operation{instruction: unit_test.instruction, target: unit_test.target, bit: uint8(b)})
 			success := true
 
 			flags_comp := CompareFlagsRegister(cpu.regs.f, unit_test.flags_result)
@@ -1640,7 +1658,7 @@ func TestBitReset(t *testing.T) {
 		unit_test.updateRegisters(&cpu)
 		// t :=
 		for b := range 8 {
-			cpu.execute(unit_test.instruction, unit_test.target, uint8(b))
+			cpu.execute(operation{instruction: unit_test.instruction, target: unit_test.target, bit: uint8(b)})
 			success := true
 
 			flags_comp := CompareFlagsRegister(cpu.regs.f, unit_test.flags_result)
@@ -1691,7 +1709,7 @@ func TestBitSet(t *testing.T) {
 		unit_test.updateRegisters(&cpu)
 		// t :=
 		for b := range 8 {
-			cpu.execute(unit_test.instruction, unit_test.target, uint8(b))
+			cpu.execute(operation{instruction: unit_test.instruction, target: unit_test.target, bit: uint8(b)})
 			success := true
 
 			flags_comp := CompareFlagsRegister(cpu.regs.f, unit_test.flags_result)
@@ -1716,3 +1734,55 @@ func TestBitSet(t *testing.T) {
 	// 10100110
 	// 00101101
 }
+
+func TestRegistersPairs(t *testing.T) {
+	type test struct {
+		title    string
+		input    uint16
+		set      func(r *Registers, value uint16)
+		get      func(r *Registers) uint16
+		expected uint16
+	}
+	tests := []test{
+		{
+			title:    "de",
+			input:    0xA62D,
+			set:      (*Registers).setDE,
+			get:      (*Registers).getDE,
+			expected: 0xA62D,
+		},
+		{
+			title:    "hl",
+			input:    0xFF01,
+			set:      (*Registers).setHL,
+			get:      (*Registers).getHL,
+			expected: 0xFF01,
+		},
+		{
+			// 11110000 survives but 00001111 doesnt exist in f
+			title:    "af drops the low nibble of f",
+			input:    0x12FF,
+			set:      (*Registers).setAF,
+			get:      (*Registers).getAF,
+			expected: 0x12F0,
+		},
+		{
+			title:    "af only some flags",
+			input:    0x01A5,
+			set:      (*Registers).setAF,
+			get:      (*Registers).getAF,
+			expected: 0x01A0,
+		},
+	}
+
+	for _, unit_test := range tests {
+		var tmp Registers
+		unit_test.set(&tmp, unit_test.input)
+		result := unit_test.get(&tmp)
+		if result != unit_test.expected {
+			t.Errorf("failed : %s expected : 0x%04X got : 0x%04X", unit_test.title, unit_test.expected, result)
+		} else {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package cpu
+
+// t-cycles in one m-cycle, the cpu never does anything shorter than this
+const M_CYCLE = 4
+
+// anything that has to run in lockstep with the cpu (ppu, timer, apu,
+// serial...), Advance gets called with the t-cycles that just went by
+type Component interface {
+	Advance(cycles uint8)
+}
+
+// Connect makes the components advance every time the cpu spends a cycle,
+// they are advanced one m-cycle at a time in the middle of the instructions
+// so a timer that overflows during a long instruction is seen at the right
+// moment
+func (cpu *CPU) Connect(components ...Component) {
+	cpu.components = append(cpu.components, components...)
+}
+
+// Cycles returns the t-cycles since the cpu started
+func (cpu *CPU) Cycles() uint64 {
+	return cpu.cycles
+}
+
+// spends one m-cycle, the bus accesses call it and the instructions that do
+// some work without touching the bus (16 bit math, taken jumps...) call it
+// for each of those internal m-cycles
+func (cpu *CPU) tick() {
+	cpu.cycles += M_CYCLE
+	for _, component := range cpu.components {
+		component.Advance(M_CYCLE)
+	}
+}
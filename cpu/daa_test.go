@@ -0,0 +1,81 @@
+package cpu
+
+import "testing"
+
+// the algorithm from https://gbdev.io/pandocs written the other way around
+// to check daa against something that is not a copy of it
+func referenceDAA(a uint8, flags FlagsRegister) (uint8, FlagsRegister) {
+	carry := flags.carry
+	if !flags.substract {
+		if flags.carry || a > 0x99 {
+			a += 0x60
+			carry = true
+		}
+		if flags.half_carry || a&0x0F > 0x09 {
+			a += 0x06
+		}
+	} else {
+		if flags.carry {
+			a -= 0x60
+		}
+		if flags.half_carry {
+			a -= 0x06
+		}
+	}
+	return a, FlagsRegister{
+		zero:       a == 0,
+		substract:  flags.substract,
+		half_carry: false,
+		carry:      carry,
+	}
+}
+
+func TestDAAExhaustive(t *testing.T) {
+	for a := range 256 {
+		for flags := range 16 {
+			cpu := CPU{}
+			cpu.regs.a = reg(a)
+			cpu.regs.f = toFlagReg(uint8(flags << 4))
+			cpu.execute(operation{instruction: DAA})
+
+			expected_a, expected_f := referenceDAA(uint8(a), toFlagReg(uint8(flags<<4)))
+			if cpu.regs.a != reg(expected_a) {
+				t.Errorf("failed : daa a : 0x%02X f : %04b expected : 0x%02X got : 0x%02X", a, flags, expected_a, cpu.regs.a)
+			}
+			if flags_comp := CompareFlagsRegister(cpu.regs.f, expected_f); flags_comp != nil {
+				t.Errorf("failed : daa a : 0x%02X f : %04b expected : %v got : %v", a, flags, expected_f, cpu.regs.f)
+				t.Error(flags_comp.Error())
+			}
+		}
+	}
+}
+
+// adding any two bcd numbers and adjusting must give the bcd of the sum
+func TestDAAAddition(t *testing.T) {
+	bcd := func(n int) reg {
+		return reg(n/10<<4 | n%10)
+	}
+	for x := range 100 {
+		for y := range 100 {
+			cpu := CPU{}
+			cpu.regs.a = bcd(x)
+			cpu.regs.b = bcd(y)
+			cpu.execute(operation{instruction: ADD, target: B})
+			cpu.execute(operation{instruction: DAA})
+			if cpu.regs.a != bcd((x+y)%100) || cpu.regs.f.carry != (x+y >= 100) {
+				t.Errorf("failed : daa %d + %d expected : 0x%02X got : 0x%02X carry : %v", x, y, bcd((x+y)%100), cpu.regs.a, cpu.regs.f.carry)
+			}
+		}
+	}
+}
+
+func TestDAAStep(t *testing.T) {
+	// LD A,0x45 ADD A,0x38 DAA
+	cpu, _ := newTestCPU(0x3E, 0x45, 0xC6, 0x38, 0x27)
+	cpu.Step()
+	cpu.Step()
+	cycles := cpu.Step()
+	if cpu.regs.a != 0x83 || cycles != 4 {
+		t.Errorf("failed : daa expected : 0x83 got : 0x%02X", cpu.regs.a)
+	}
+}
@@ -0,0 +1,19 @@
+package cpu
+
+// HALT stops running instructions until an interrupt is pending (IE & IF),
+// if ime is off and there is already one pending the cpu doesnt halt at all
+// and the halt bug happens instead, the byte after HALT is read twice
+// https://gbdev.io/pandocs/halt.html
+func (cpu *CPU) halt() {
+	if !cpu.ime && cpu.pendingInterrupts() != 0 {
+		cpu.halt_bug = true
+		return
+	}
+	cpu.halted = true
+}
+
+// STOP turns off the cpu (and the screen on real hardware) until a button
+// is pressed, the joypad lets the cpu know through RequestInterrupt
+func (cpu *CPU) stop() {
+	cpu.stopped = true
+}
@@ -0,0 +1,128 @@
+package cpu
+
+import "testing"
+
+func TestHaltWithIME(t *testing.T) {
+	// HALT, INC B
+	cpu, _ := newTestCPU(0x76, 0x04)
+	cpu.regs.sp = 0xFFFE
+	cpu.ime = true
+	cpu.interrupt_enable = 1 << VBlank
+
+	cpu.Step()
+	for range 10 {
+		if cycles := cpu.Step(); cycles != 4 {
+			t.Errorf("failed : halted expected : 4 cycles got : %d", cycles)
+		}
+	}
+	if cpu.regs.pc != 1 || cpu.regs.b != 0 {
+		t.Errorf("failed : halt expected to idle at pc : 0x0001 got : 0x%04X", cpu.regs.pc)
+	}
+
+	cpu.RequestInterrupt(VBlank)
+	cycles := cpu.Step()
+	if cpu.regs.pc != 0x40 || cycles != 20 {
+		t.Errorf("failed : halt expected to service vblank got pc : 0x%04X", cpu.regs.pc)
+	}
+	// the handler returns to the instruction after HALT
+	if cpu.pop() != 0x0001 {
+		t.Errorf("failed : halt expected return address 0x0001")
+	}
+}
+
+func TestHaltWithoutIME(t *testing.T) {
+	// HALT, INC B
+	cpu, _ := newTestCPU(0x76, 0x04)
+	cpu.interrupt_enable = 1 << Timer
+
+	cpu.Step()
+	cpu.Step()
+	if !cpu.halted {
+		t.Errorf("failed : halt expected the cpu to be halted")
+	}
+
+	// ime is off so it wakes up but the interrupt is not serviced
+	cpu.RequestInterrupt(Timer)
+	cpu.Step()
+	if cpu.regs.pc != 2 || cpu.regs.b != 1 {
+		t.Errorf("failed : halt expected to continue after waking up got pc : 0x%04X b : %d", cpu.regs.pc, cpu.regs.b)
+	}
+	if cpu.interrupt_flag != 1<<Timer {
+		t.Errorf("failed : halt expected the interrupt to still be requested")
+	}
+}
+
+func TestHaltIgnoresDisabledInterrupts(t *testing.T) {
+	cpu, _ := newTestCPU(0x76, 0x04)
+	cpu.ime = true
+	cpu.interrupt_enable = 1 << Timer
+	cpu.Step()
+	cpu.RequestInterrupt(Serial)
+	cpu.Step()
+	if !cpu.halted || cpu.regs.pc != 1 {
+		t.Errorf("failed : halt expected to stay halted for a disabled interrupt")
+	}
+}
+
+func TestHaltBug(t *testing.T) {
+	// HALT, INC B, INC C
+	cpu, _ := newTestCPU(0x76, 0x04, 0x0C)
+	cpu.interrupt_enable = 1 << Joypad
+	cpu.RequestInterrupt(Joypad)
+
+	for range 3 {
+		cpu.Step()
+	}
+	if cpu.halted {
+		t.Errorf("failed : halt bug expected the cpu to not halt")
+	}
+	// INC B runs twice because pc doesnt move after fetching it the first
+	// time
+	if cpu.regs.b != 2 || cpu.regs.c != 0 || cpu.regs.pc != 2 {
+		t.Errorf("failed : halt bug expected b : 2 c : 0 pc : 0x0002 got : %v", &cpu.regs)
+	}
+}
+
+func TestHaltBugAfterEI(t *testing.T) {
+	// EI, HALT, INC B
+	cpu, _ := newTestCPU(0xFB, 0x76, 0x04)
+	cpu.regs.sp = 0xFFFE
+	cpu.interrupt_enable = 1 << VBlank
+	cpu.RequestInterrupt(VBlank)
+
+	cpu.Step()
+	cpu.Step()
+	cpu.Step()
+	if cpu.regs.pc != 0x40 {
+		t.Errorf("failed : ei halt expected pc : 0x0040 got : 0x%04X", cpu.regs.pc)
+	}
+	// the handler returns to the HALT so it gets executed again
+	if cpu.pop() != 0x0001 {
+		t.Errorf("failed : ei halt expected to return to the halt")
+	}
+}
+
+func TestStop(t *testing.T) {
+	// STOP 0, INC B
+	cpu, _ := newTestCPU(0x10, 0x00, 0x04)
+	cpu.Step()
+	for range 5 {
+		cpu.Step()
+	}
+	if cpu.regs.pc != 2 || cpu.regs.b != 0 {
+		t.Errorf("failed : stop expected to wait at pc : 0x0002 got : 0x%04X", cpu.regs.pc)
+	}
+
+	// other interrupts dont wake it up
+	cpu.RequestInterrupt(VBlank)
+	cpu.Step()
+	if cpu.regs.b != 0 {
+		t.Errorf("failed : stop expected to ignore vblank")
+	}
+
+	cpu.RequestInterrupt(Joypad)
+	cpu.Step()
+	if cpu.regs.pc != 3 || cpu.regs.b != 1 {
+		t.Errorf("failed : stop expected to continue after a button got pc : 0x%04X", cpu.regs.pc)
+	}
+}
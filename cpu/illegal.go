@@ -0,0 +1,56 @@
+package cpu
+
+import "fmt"
+
+// IllegalOpcodeError is what Err returns after the cpu ran one of the opcodes
+// that dont exist on the sm83 (0xD3, 0xDB, 0xDD, 0xE3, 0xE4, 0xEB, 0xEC, 0xED,
+// 0xF4, 0xFC and 0xFD)
+type IllegalOpcodeError struct {
+	Opcode uint8
+	// where the opcode was fetched from and the rom bank mapped there
+	PC   uint16
+	Bank uint16
+}
+
+func (err *IllegalOpcodeError) Error() string {
+	return fmt.Sprintf("illegal opcode 0x%02X at %02X:%04X", err.Opcode, err.Bank, err.PC)
+}
+
+// the real cpu hangs when it decodes an illegal opcode, it stops fetching and
+// doesnt even service interrupts, the only way out is turning it off and on
+// again, the rest of the hardware keeps running so Step still spends cycles
+func (cpu *CPU) lock(opcode uint8, address uint16) {
+	cpu.locked = &IllegalOpcodeError{
+		Opcode: opcode,
+		PC:     address,
+		Bank:   cpu.bank(address),
+	}
+}
+
+// without a BankedBus the rom is assumed to be 32kb with no banking
+func (cpu *CPU) bank(address uint16) uint16 {
+	if bus, ok := cpu.bus.(BankedBus); ok {
+		return bus.Bank(address)
+	}
+	if address >= 0x4000 && address < 0x8000 {
+		return 1
+	}
+	return 0
+}
+
+// Err returns an *IllegalOpcodeError if the cpu is locked up or nil if it is
+// running normally
+func (cpu *CPU) Err() error {
+	return cpu.locked
+}
+
+// Reset clears the cpu like it is before anything runs on it, the bus, the
+// connected components and the cycle count are kept, the registers the boot
+// rom leaves are set by whoever owns the cpu (see gameboy.GameBoy.Reset)
+func (cpu *CPU) Reset() {
+	*cpu = CPU{
+		bus:        cpu.bus,
+		cycles:     cpu.cycles,
+		components: cpu.components,
+	}
+}
@@ -0,0 +1,103 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+)
+
+// bank switching bus that always reports the same bank
+type bankedTestBus struct {
+	testBus
+	bank uint16
+}
+
+func (b *bankedTestBus) Bank(address uint16) uint16 {
+	if address < 0x4000 {
+		return 0
+	}
+	return b.bank
+}
+
+func TestIllegalOpcodes(t *testing.T) {
+	for _, code := range []uint8{0xD3, 0xDB, 0xDD, 0xE3, 0xE4, 0xEB, 0xEC, 0xED, 0xF4, 0xFC, 0xFD} {
+		// NOP, illegal, INC B
+		cpu, _ := newTestCPU(0x00, code, 0x04)
+		cpu.regs.sp = 0xFFFE
+		cpu.ime = true
+		cpu.interrupt_enable = 0x1F
+		counter := &cycleCounter{}
+		cpu.Connect(counter)
+
+		cpu.Step()
+		if cpu.Err() != nil {
+			t.Errorf("failed : 0x%02X expected no error before running it", code)
+		}
+		if cycles := cpu.Step(); cycles != 4 {
+			t.Errorf("failed : 0x%02X expected : 4 cycles got : %d", code, cycles)
+		}
+
+		var illegal *IllegalOpcodeError
+		if !errors.As(cpu.Err(), &illegal) {
+			t.Errorf("failed : 0x%02X expected an illegal opcode error got : %v", code, cpu.Err())
+			continue
+		}
+		if illegal.Opcode != code || illegal.PC != 0x0001 || illegal.Bank != 0 {
+			t.Errorf("failed : 0x%02X expected : opcode 0x%02X pc 0x0001 bank 0 got : %v", code, code, illegal)
+		}
+
+		// locked up, interrupts are ignored but time keeps going
+		cpu.RequestInterrupt(VBlank)
+		for range 10 {
+			if cycles := cpu.Step(); cycles != 4 {
+				t.Errorf("failed : 0x%02X locked expected : 4 cycles got : %d", code, cycles)
+			}
+		}
+		if cpu.regs.pc != 2 || cpu.regs.b != 0 || cpu.regs.sp != 0xFFFE {
+			t.Errorf("failed : 0x%02X expected the cpu to stay locked got : %v", code, &cpu.regs)
+		}
+		if counter.cycles != 48 {
+			t.Errorf("failed : 0x%02X expected the components to advance 48 cycles got : %d", code, counter.cycles)
+		}
+	}
+}
+
+func TestIllegalOpcodeBank(t *testing.T) {
+	bus := &bankedTestBus{bank: 0x1F}
+	bus.testBus[0x4567] = 0xFD
+	cpu := New(bus)
+	cpu.regs.pc = 0x4567
+	cpu.Step()
+
+	expected := "illegal opcode 0xFD at 1F:4567"
+	if cpu.Err() == nil || cpu.Err().Error() != expected {
+		t.Errorf("failed : expected : %s got : %v", expected, cpu.Err())
+	}
+
+	// a plain bus maps bank 1 at 0x4000
+	cpu, test_bus := newTestCPU()
+	test_bus[0x4000] = 0xD3
+	cpu.regs.pc = 0x4000
+	cpu.Step()
+	if cpu.Err() == nil || cpu.Err().(*IllegalOpcodeError).Bank != 1 {
+		t.Errorf("failed : expected bank 1 got : %v", cpu.Err())
+	}
+}
+
+func TestResetUnlocks(t *testing.T) {
+	// illegal, INC B
+	cpu, _ := newTestCPU(0xD3, 0x04)
+	cpu.regs.b = 5
+	cpu.Step()
+	cpu.Reset()
+	if cpu.Err() != nil || cpu.regs.pc != 0 || cpu.regs.b != 0 {
+		t.Errorf("failed : reset expected a fresh cpu got : %v %v", cpu.Err(), &cpu.regs)
+	}
+	if cpu.Cycles() != 4 {
+		t.Errorf("failed : reset expected to keep the cycles got : %d", cpu.Cycles())
+	}
+	// runs the illegal opcode again
+	cpu.Step()
+	if cpu.Err() == nil {
+		t.Errorf("failed : expected to lock up again")
+	}
+}
@@ -1,7 +1,5 @@
 package cpu
 
-import "fmt"
-
 const (
 	ADD Instruction = iota
 	ADDHL
@@ -41,14 +39,129 @@ const (
 	SRL
 
 	SWAP
+
+	NOP
+	DAA
+	LD
+	LD16
+	LDHL
+	INC16
+	DEC16
+	ADDSP
+
+	JP
+	JPHL
+	JR
+	CALL
+	RET
+	RETI
+	RST
+
+	PUSH
+	POP
+
+	DI
+	EI
+	HALT
+	STOP
+	PREFIX
+	ILLEGAL
 )
 
-// remove the target field please and probably should hardcode the other operations because yeah uwu
-func (cpu *CPU) execute(instruction Instruction, target ArithmeticTarget, args ...any) {
-	switch instruction {
+// returns the t-cycles the instruction spent once it was fetched, memory
+// operands, the stack and internal delays, Step adds the fetch on top
+// the operation is expected to be valid, see operation.validate
+func (cpu *CPU) execute(op operation) uint8 {
+	start := cpu.cycles
+	cpu.imm = op.immediate
+	target := op.target
+	switch op.instruction {
+	case NOP:
+		{
+		}
+	case HALT:
+		{
+			cpu.halt()
+		}
+	case STOP:
+		{
+			cpu.stop()
+		}
+	case DAA:
+		{
+			cpu.daa()
+		}
+	case LD:
+		{
+			cpu.load(target, op.source)
+		}
+	case LD16:
+		{
+			cpu.load16(target, op.source)
+		}
+	case LDHL:
+		{
+			cpu.loadHLSP()
+		}
+	case JP:
+		{
+			cpu.jump(op.test)
+		}
+	case JR:
+		{
+			cpu.jumpRelative(op.test)
+		}
+	case CALL:
+		{
+			cpu.call(op.test)
+		}
+	case RET:
+		{
+			cpu.ret(op.test)
+		}
+	case JPHL:
+		{
+			cpu.jumpHL()
+		}
+	case RST:
+		{
+			cpu.rst(op.vector)
+		}
+	case RETI:
+		{
+			cpu.reti()
+		}
+	case DI:
+		{
+			cpu.di()
+		}
+	case EI:
+		{
+			cpu.ei()
+		}
+	case PUSH:
+		{
+			cpu.pushTarget(target)
+		}
+	case POP:
+		{
+			cpu.popTarget(target)
+		}
 	case ADDHL:
 		{
-			panic("not implemented yet because memory doesnt exist yet")
+			cpu.addHL(target)
+		}
+	case ADDSP:
+		{
+			cpu.addSP()
+		}
+	case INC16:
+		{
+			cpu.inc16(target)
+		}
+	case DEC16:
+		{
+			cpu.dec16(target)
 		}
 	case ADD:
 		{
@@ -104,11 +217,11 @@ func (cpu *CPU) execute(instruction Instruction, target ArithmeticTarget, args .
 		}
 	case RLA:
 		{
-			cpu.rotateLeftThroughCarry(true, A)
+			cpu.rotateLeftThroughCarry(false, A)
 		}
 	case RLCA:
 		{
-			cpu.rotateLeft(true, A)
+			cpu.rotateLeft(false, A)
 		}
 	case RR:
 		{
@@ -116,7 +229,7 @@ func (cpu *CPU) execute(instruction Instruction, target ArithmeticTarget, args .
 		}
 	case RRA:
 		{
-			cpu.rotateRightThroughCarry(true, A)
+			cpu.rotateRightThroughCarry(false, A)
 		}
 	case RLC:
 		{
@@ -128,7 +241,7 @@ func (cpu *CPU) execute(instruction Instruction, target ArithmeticTarget, args .
 		}
 	case RRCA:
 		{
-			cpu.rotateRight(true, A)
+			cpu.rotateRight(false, A)
 		}
 	case SLL:
 		{
@@ -146,57 +259,47 @@ func (cpu *CPU) execute(instruction Instruction, target ArithmeticTarget, args .
 		{
 			cpu.shiftRightLogical(true, target)
 		}
+	case SWAP:
+		{
+			cpu.swap(target)
+		}
 	case CPL:
 		{
 			cpu.cpl()
 		}
 	case BIT:
 		{
-			if len(args) > 0 {
-				if n_bit, ok := args[0].(uint8); ok {
-					cpu.bit(n_bit, target)
-
-				} else {
-					fmt.Printf("should be an uint8 %v\n", args)
-				}
-			} else {
-				fmt.Println("expected which bit to check for BIT operation")
-			}
+			cpu.bit(op.bit, target)
 		}
 	case RESET:
 		{
-			if len(args) > 0 {
-				if n_bit, ok := args[0].(uint8); ok {
-					cpu.reset(n_bit, target)
-				} else {
-					fmt.Printf("should be an uint8 %v\n", args)
-				}
-			} else {
-				fmt.Println("expected which bit to check for BIT operation")
-			}
+			cpu.reset(op.bit, target)
 		}
 	case SET:
 		{
-			if len(args) > 0 {
-				if n_bit, ok := args[0].(uint8); ok {
-					cpu.set(n_bit, target)
-				} else {
-					fmt.Printf("should be an uint8 %v\n", args)
-				}
-			} else {
-				fmt.Println("expected which bit to check for BIT operation")
-			}
+			cpu.set(op.bit, target)
 		}
 	}
+
+	// the handlers only modify the copy of (hl) so it has to be stored back
+	if cpu.mem_dirty {
+		cpu.write(cpu.regs.getHL(), uint8(cpu.mem))
+		cpu.mem_dirty = false
+	}
+	return uint8(cpu.cycles - start)
 }
 
-// this updates the a register always, might refactor the registers to be an array please please please?
-// we also do something about the half carry weird here cause we use the a register always which aint bad because all the math operations are performed there but be careful also, cause some of them might not do it
-// unrefactor this cause this might break things hehe
+// flags of the 8 bit math on A, it has to be called before A gets the new
+// value because the half carry is worked out from the old one, the carry out
+// of bit 3 when adding and the borrow into it when substracting
 func (cpu *CPU) updateFlags(value, new_value reg, did_overflow, is_substracting bool) {
 	cpu.regs.f.zero = new_value == 0
 	cpu.regs.f.substract = is_substracting
-	cpu.regs.f.half_carry = (cpu.regs.a&0xF)+(value&0xF) > 0xF
+	if is_substracting {
+		cpu.regs.f.half_carry = cpu.regs.a&0xF < value&0xF
+	} else {
+		cpu.regs.f.half_carry = (cpu.regs.a&0xF)+(value&0xF) > 0xF
+	}
 	cpu.regs.f.carry = did_overflow
 }
 
@@ -210,8 +313,78 @@ const (
 	E
 	H
 	L
+	// the byte in memory pointed by hl, (HL)
+	HLI
+	// the byte that comes right after the opcode, d8
+	D8
+	// the byte in memory pointed by bc, (BC)
+	BCI
+	// the byte in memory pointed by de, (DE)
+	DEI
+	// (HL+) and (HL-), the byte pointed by hl and then hl gets incremented
+	// or decremented
+	HLIP
+	HLIM
+	// the byte at the address that comes after the opcode, (a16)
+	A16I
+	// the high page, 0xFF00 plus the byte after the opcode, (a8)
+	A8I
+	// the high page, 0xFF00 plus c, (C)
+	CI
+
+	// 16 bit targets, only the 16 bit instructions use them
+	BC
+	DE
+	HL
+	SP
+	AF
+	// the two bytes that come right after the opcode, d16
+	D16
 )
 
+// returns the address the memory targets point to, (hl+) and (hl-) update hl
+// so this must be called only once per instruction
+func (cpu *CPU) getAddressByTarget(target ArithmeticTarget) uint16 {
+	var address uint16
+	switch target {
+	case HLI:
+		{
+			address = cpu.regs.getHL()
+		}
+	case HLIP:
+		{
+			address = cpu.regs.getHL()
+			cpu.regs.setHL(address + 1)
+		}
+	case HLIM:
+		{
+			address = cpu.regs.getHL()
+			cpu.regs.setHL(address - 1)
+		}
+	case BCI:
+		{
+			address = cpu.regs.getBC()
+		}
+	case DEI:
+		{
+			address = cpu.regs.getDE()
+		}
+	case A16I:
+		{
+			address = cpu.imm
+		}
+	case A8I:
+		{
+			address = 0xFF00 | cpu.imm&0xFF
+		}
+	case CI:
+		{
+			address = 0xFF00 | uint16(cpu.regs.c)
+		}
+	}
+	return address
+}
+
 func (cpu *CPU) getRegisterValueByTarget(target ArithmeticTarget) reg {
 	var target_reg reg
 	// cpu.regs[target] in c lovely c i would have done this :3
@@ -244,6 +417,14 @@ func (cpu *CPU) getRegisterValueByTarget(target ArithmeticTarget) reg {
 		{
 			target_reg = cpu.regs.l
 		}
+	case HLI, HLIP, HLIM, BCI, DEI, A16I, A8I, CI:
+		{
+			target_reg = reg(cpu.read(cpu.getAddressByTarget(target)))
+		}
+	case D8:
+		{
+			target_reg = reg(cpu.imm)
+		}
 	}
 	return target_reg
 }
@@ -280,10 +461,36 @@ func (cpu *CPU) getRegisterByTarget(target ArithmeticTarget) *reg {
 		{
 			target_reg = &cpu.regs.l
 		}
+	case HLI:
+		{
+			cpu.mem = reg(cpu.read(cpu.regs.getHL()))
+			cpu.mem_dirty = true
+			target_reg = &cpu.mem
+		}
+	case D8:
+		{
+			cpu.mem = reg(cpu.imm)
+			target_reg = &cpu.mem
+		}
 	}
 	return target_reg
 }
 
+// stores the value in the target, unlike getRegisterByTarget memory targets
+// are written straight away without reading them first
+func (cpu *CPU) setRegisterByTarget(target ArithmeticTarget, value reg) {
+	switch target {
+	case HLI, HLIP, HLIM, BCI, DEI, A16I, A8I, CI:
+		{
+			cpu.write(cpu.getAddressByTarget(target), uint8(value))
+		}
+	default:
+		{
+			*cpu.getRegisterByTarget(target) = value
+		}
+	}
+}
+
 func (cpu *CPU) add(target ArithmeticTarget) {
 	value := cpu.getRegisterValueByTarget(target)
 	new_value, did_overflow := cpu.regs.a.overflowingAdd(value)
@@ -291,13 +498,20 @@ func (cpu *CPU) add(target ArithmeticTarget) {
 	cpu.regs.a = new_value
 }
 
+// the carry flag is added on top of the value, the half carry and carry
+// have to take it into account too so updateFlags cant be used
 func (cpu *CPU) addWithCarry(target ArithmeticTarget) {
 	value := cpu.getRegisterValueByTarget(target)
-	new_value, did_overflow := cpu.regs.a.overflowingAdd(value)
-	if did_overflow {
-		new_value += 1
+	var carry reg
+	if cpu.regs.f.carry {
+		carry = 1
 	}
-	cpu.updateFlags(value, new_value, did_overflow, false)
+	new_value, did_overflow := cpu.regs.a.overflowingAdd(value)
+	new_value, did_overflow_carry := new_value.overflowingAdd(carry)
+	cpu.regs.f.zero = new_value == 0
+	cpu.regs.f.substract = false
+	cpu.regs.f.half_carry = (cpu.regs.a&0xF)+(value&0xF)+carry > 0xF
+	cpu.regs.f.carry = did_overflow || did_overflow_carry
 	cpu.regs.a = new_value
 }
 
@@ -308,35 +522,47 @@ func (cpu *CPU) sub(target ArithmeticTarget) {
 	cpu.regs.a = new_value
 }
 
+// same as addWithCarry, the carry is one more to substract
 func (cpu *CPU) subWithCarry(target ArithmeticTarget) {
 	value := cpu.getRegisterValueByTarget(target)
-	new_value, did_overflow := cpu.regs.a.overflowingSub(value)
-	if did_overflow {
-		new_value -= 1
+	var carry reg
+	if cpu.regs.f.carry {
+		carry = 1
 	}
-	cpu.updateFlags(value, new_value, did_overflow, true)
+	new_value, did_overflow := cpu.regs.a.overflowingSub(value)
+	new_value, did_overflow_carry := new_value.overflowingSub(carry)
+	cpu.regs.f.zero = new_value == 0
+	cpu.regs.f.substract = true
+	cpu.regs.f.half_carry = cpu.regs.a&0xF < value&0xF+carry
+	cpu.regs.f.carry = did_overflow || did_overflow_carry
 	cpu.regs.a = new_value
 }
 
+// AND always sets the half carry, OR and XOR always clear it, none of them
+// can carry
 func (cpu *CPU) and(target ArithmeticTarget) {
 	value := cpu.getRegisterValueByTarget(target)
-	new_value := cpu.regs.a & value
-	cpu.updateFlags(value, new_value, false, false)
-	cpu.regs.a = new_value
+	cpu.regs.a &= value
+	cpu.setLogicFlags(true)
 }
 
 func (cpu *CPU) or(target ArithmeticTarget) {
 	value := cpu.getRegisterValueByTarget(target)
-	new_value := cpu.regs.a | value
-	cpu.updateFlags(value, new_value, false, false)
-	cpu.regs.a = new_value
+	cpu.regs.a |= value
+	cpu.setLogicFlags(false)
 }
 
 func (cpu *CPU) xor(target ArithmeticTarget) {
 	value := cpu.getRegisterValueByTarget(target)
-	new_value := cpu.regs.a ^ value
-	cpu.updateFlags(value, new_value, false, false)
-	cpu.regs.a = new_value
+	cpu.regs.a ^= value
+	cpu.setLogicFlags(false)
+}
+
+func (cpu *CPU) setLogicFlags(half_carry bool) {
+	cpu.regs.f.zero = cpu.regs.a == 0
+	cpu.regs.f.substract = false
+	cpu.regs.f.half_carry = half_carry
+	cpu.regs.f.carry = false
 }
 
 // compares the value in the target register to the one in the a register
@@ -347,20 +573,22 @@ func (cpu *CPU) cmp(target ArithmeticTarget) {
 	cpu.updateFlags(value, new_value, did_overflow, true)
 }
 
+// 8 bit inc and dec leave the carry alone, the half carry is set when the
+// low nibble wraps around
 func (cpu *CPU) inc(target ArithmeticTarget) {
 	register := cpu.getRegisterByTarget(target)
-	value := reg(1)
-	new_value, did_overflow := register.overflowingAdd(value)
-	cpu.updateFlags(value, new_value, did_overflow, false)
-	*register = new_value
+	*register++
+	cpu.regs.f.zero = *register == 0
+	cpu.regs.f.substract = false
+	cpu.regs.f.half_carry = *register&0xF == 0x0
 }
 
 func (cpu *CPU) dec(target ArithmeticTarget) {
 	register := cpu.getRegisterByTarget(target)
-	value := reg(1)
-	new_value, did_overflow := register.overflowingSub(value)
-	cpu.updateFlags(value, new_value, did_overflow, true)
-	*register = new_value
+	*register--
+	cpu.regs.f.zero = *register == 0
+	cpu.regs.f.substract = true
+	cpu.regs.f.half_carry = *register&0xF == 0xF
 }
 
 // flips the carry flag and clears the *substract* and *half_carry* *flags*
@@ -453,11 +681,7 @@ func (cpu *CPU) rotateLeftThroughCarry(set_zero bool, target ArithmeticTarget) {
 func (cpu *CPU) shiftLeftArithmetic(set_zero bool, target ArithmeticTarget) {
 	register := cpu.getRegisterByTarget(target)
 	og_value := *register
-	ari_bit := og_value & 0b1
 	*register <<= 1
-	if ari_bit == 0b1 {
-		*register |= ari_bit
-	}
 	cpu.regs.f.zero = set_zero && *register == 0
 	cpu.regs.f.substract = false
 	cpu.regs.f.half_carry = false
@@ -501,6 +725,45 @@ func (cpu *CPU) shiftRightLogical(set_zero bool, target ArithmeticTarget) {
 	cpu.regs.f.carry = og_value&0b1 == 0b1
 }
 
+// swaps the upper and lower nibbles of the target *register*
+// 10100101 -> 01011010
+func (cpu *CPU) swap(target ArithmeticTarget) {
+	register := cpu.getRegisterByTarget(target)
+	*register = *register<<4 | *register>>4
+	cpu.regs.f.zero = *register == 0
+	cpu.regs.f.substract = false
+	cpu.regs.f.half_carry = false
+	cpu.regs.f.carry = false
+}
+
+// decimal adjust, fixes A after an ADD/ADC/SUB/SBC of two bcd numbers so it
+// is bcd again (0x09 + 0x01 = 0x0A -> 0x10), it uses the substract flag to
+// know which operation happened and the half carry and carry flags to know
+// if a digit overflowed, same as references/opcodes.cpp
+func (cpu *CPU) daa() {
+	var correction reg
+	if cpu.regs.f.carry {
+		correction = 0x60
+	}
+	if cpu.regs.f.half_carry || (!cpu.regs.f.substract && cpu.regs.a&0x0F > 0x09) {
+		correction |= 0x06
+	}
+	if cpu.regs.f.carry || (!cpu.regs.f.substract && cpu.regs.a > 0x99) {
+		correction |= 0x60
+	}
+
+	if cpu.regs.f.substract {
+		cpu.regs.a -= correction
+	} else {
+		cpu.regs.a += correction
+	}
+
+	cpu.regs.f.zero = cpu.regs.a == 0
+	cpu.regs.f.half_carry = false
+	// a carry that was already set always stays set
+	cpu.regs.f.carry = correction&0x60 == 0x60
+}
+
 // invert the bits of the register A
 func (cpu *CPU) cpl() {
 	register := cpu.getRegisterByTarget(A)
@@ -513,8 +776,9 @@ func (cpu *CPU) cpl() {
 // sets the zero flag if is not set
 // sets the half carry to 1 for some reason
 func (cpu *CPU) bit(n_bit uint8, target ArithmeticTarget) {
-	register := cpu.getRegisterByTarget(target)
-	bit_in_reg := *register >> reg(n_bit)
+	// only reads the value, so (hl) doesnt get written back
+	register := cpu.getRegisterValueByTarget(target)
+	bit_in_reg := register >> reg(n_bit)
 	// 00010000
 	// 76543210
 	cpu.regs.f.substract = false
@@ -0,0 +1,82 @@
+package cpu
+
+// the sources that can interrupt the cpu, the value is the bit they use in
+// IE and IF and also their priority, the lowest bit gets serviced first
+type Interrupt uint8
+
+const (
+	VBlank Interrupt = iota
+	LCDStat
+	Timer
+	Serial
+	Joypad
+)
+
+const (
+	IE_ADDRESS = 0xFFFF
+	IF_ADDRESS = 0xFF0F
+	// each interrupt jumps to 0x40 + 8 * its bit
+	INTERRUPT_VECTOR = 0x40
+)
+
+// RequestInterrupt sets the bit of the interrupt in IF, the other components
+// (ppu, timer, serial, joypad) use this to ask for the cpu attention
+func (cpu *CPU) RequestInterrupt(interrupt Interrupt) {
+	cpu.interrupt_flag |= 1 << interrupt
+	// the joypad interrupt comes from a button being pressed which is what
+	// STOP is waiting for
+	if interrupt == Joypad {
+		cpu.stopped = false
+	}
+}
+
+// interrupts that are both requested and enabled
+func (cpu *CPU) pendingInterrupts() uint8 {
+	return cpu.interrupt_enable & cpu.interrupt_flag & 0x1F
+}
+
+// jumps to the vector of the highest priority pending interrupt, it
+// acknowledges it by clearing its bit in IF and disables any other interrupt
+// until the handler re enables them (usually with RETI), it takes 5 m-cycles,
+// 2 waiting, 2 pushing pc and 1 jumping
+func (cpu *CPU) serviceInterrupt() {
+	pending := cpu.pendingInterrupts()
+	var interrupt Interrupt
+	for pending&(1<<interrupt) == 0 {
+		interrupt++
+	}
+	cpu.ime = false
+	cpu.ime_scheduled = false
+	cpu.tick()
+	cpu.tick()
+	cpu.interrupt_flag &^= 1 << interrupt
+	// EI followed by a HALT that triggers the halt bug returns to the HALT
+	// instead of the byte after it
+	if cpu.halt_bug {
+		cpu.halt_bug = false
+		cpu.regs.pc--
+	}
+	cpu.push(cpu.regs.pc)
+	cpu.regs.pc = INTERRUPT_VECTOR + 8*uint16(interrupt)
+	cpu.tick()
+}
+
+// DI disables the interrupts right away and also cancels a previous EI that
+// didnt take effect yet
+func (cpu *CPU) di() {
+	cpu.ime = false
+	cpu.ime_scheduled = false
+}
+
+// EI enables the interrupts after the next instruction, that way EI followed
+// by RET returns before any interrupt can happen
+func (cpu *CPU) ei() {
+	cpu.ime_scheduled = true
+}
+
+// RETI is RET plus enabling the interrupts, this one has no delay
+func (cpu *CPU) reti() {
+	cpu.regs.pc = cpu.pop()
+	cpu.tick()
+	cpu.ime = true
+}
@@ -0,0 +1,185 @@
+package cpu
+
+import "testing"
+
+func TestInterruptDispatch(t *testing.T) {
+	type test struct {
+		title     string
+		enable    uint8
+		requested []Interrupt
+		vector    uint16
+		remaining uint8
+	}
+
+	tests := []test{
+		{
+			title:     "vblank",
+			enable:    0x1F,
+			requested: []Interrupt{VBlank},
+			vector:    0x40,
+		},
+		{
+			title:     "lcd stat",
+			enable:    0x1F,
+			requested: []Interrupt{LCDStat},
+			vector:    0x48,
+		},
+		{
+			title:     "timer",
+			enable:    0x1F,
+			requested: []Interrupt{Timer},
+			vector:    0x50,
+		},
+		{
+			title:     "serial",
+			enable:    0x1F,
+			requested: []Interrupt{Serial},
+			vector:    0x58,
+		},
+		{
+			title:     "joypad",
+			enable:    0x1F,
+			requested: []Interrupt{Joypad},
+			vector:    0x60,
+		},
+		{
+			title:     "priority timer over joypad",
+			enable:    0x1F,
+			requested: []Interrupt{Joypad, Timer},
+			vector:    0x50,
+			remaining: 1 << Joypad,
+		},
+		{
+			title:     "disabled vblank is skipped",
+			enable:    1<<Serial | 1<<LCDStat,
+			requested: []Interrupt{VBlank, Serial},
+			vector:    0x58,
+			remaining: 1 << VBlank,
+		},
+	}
+
+	for _, unit_test := range tests {
+		cpu, bus := newTestCPU()
+		cpu.regs.pc = 0x1234
+		cpu.regs.sp = 0xFFFE
+		cpu.ime = true
+		cpu.interrupt_enable = unit_test.enable
+		for _, interrupt := range unit_test.requested {
+			cpu.RequestInterrupt(interrupt)
+		}
+
+		cycles := cpu.Step()
+		success := true
+		if cpu.regs.pc != unit_test.vector {
+			t.Errorf("failed : %s expected pc : 0x%04X got : 0x%04X", unit_test.title, unit_test.vector, cpu.regs.pc)
+			success = false
+		}
+		if cycles != 20 {
+			t.Errorf("failed : %s expected : 20 cycles got : %d", unit_test.title, cycles)
+			success = false
+		}
+		if cpu.ime {
+			t.Errorf("failed : %s expected ime to be disabled", unit_test.title)
+			success = false
+		}
+		if cpu.interrupt_flag != unit_test.remaining {
+			t.Errorf("failed : %s expected if : %05b got : %05b", unit_test.title, unit_test.remaining, cpu.interrupt_flag)
+			success = false
+		}
+		if cpu.regs.sp != 0xFFFC || bus[0xFFFD] != 0x12 || bus[0xFFFC] != 0x34 {
+			t.Errorf("failed : %s expected pc to be pushed", unit_test.title)
+			success = false
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
+
+func TestInterruptNotServiced(t *testing.T) {
+	// NOP
+	cpu, _ := newTestCPU(0x00)
+	cpu.interrupt_enable = 0x1F
+	cpu.RequestInterrupt(VBlank)
+	cpu.Step()
+	if cpu.regs.pc != 1 {
+		t.Errorf("failed : ime off expected pc : 1 got : 0x%04X", cpu.regs.pc)
+	}
+
+	cpu, _ = newTestCPU(0x00)
+	cpu.ime = true
+	cpu.RequestInterrupt(VBlank)
+	cpu.Step()
+	if cpu.regs.pc != 1 {
+		t.Errorf("failed : ie off expected pc : 1 got : 0x%04X", cpu.regs.pc)
+	}
+}
+
+func TestEIDelay(t *testing.T) {
+	// EI, NOP, NOP
+	cpu, _ := newTestCPU(0xFB, 0x00, 0x00)
+	cpu.regs.sp = 0xFFFE
+	cpu.interrupt_enable = 0x1F
+	cpu.RequestInterrupt(Timer)
+
+	cpu.Step()
+	if cpu.ime {
+		t.Errorf("failed : ei expected ime to still be off")
+	}
+	cpu.Step()
+	if cpu.regs.pc != 2 || !cpu.ime {
+		t.Errorf("failed : ei expected the nop to run before the interrupt, pc : 0x%04X", cpu.regs.pc)
+	}
+	cpu.Step()
+	if cpu.regs.pc != 0x50 {
+		t.Errorf("failed : ei expected pc : 0x0050 got : 0x%04X", cpu.regs.pc)
+	}
+}
+
+func TestEIThenDI(t *testing.T) {
+	// EI, DI, NOP
+	cpu, _ := newTestCPU(0xFB, 0xF3, 0x00)
+	cpu.interrupt_enable = 0x1F
+	cpu.RequestInterrupt(Timer)
+	for range 3 {
+		cpu.Step()
+	}
+	if cpu.regs.pc != 3 || cpu.ime {
+		t.Errorf("failed : ei di expected no interrupt, pc : 0x%04X", cpu.regs.pc)
+	}
+}
+
+func TestRETI(t *testing.T) {
+	cpu, bus := newTestCPU(0xD9)
+	cpu.regs.sp = 0xFFFC
+	bus[0xFFFC] = 0x00
+	bus[0xFFFD] = 0x02
+	cycles := cpu.Step()
+	if cpu.regs.pc != 0x0200 || cpu.regs.sp != 0xFFFE {
+		t.Errorf("failed : reti expected pc : 0x0200 got : 0x%04X", cpu.regs.pc)
+	}
+	if !cpu.ime {
+		t.Errorf("failed : reti expected ime to be enabled right away")
+	}
+	if cycles != 16 {
+		t.Errorf("failed : reti expected : 16 cycles got : %d", cycles)
+	}
+}
+
+func TestInterruptRegisters(t *testing.T) {
+	// LD A,0x05 LDH (0x0F),A LDH (0xFF),A LD A,(0xFF0F)
+	cpu, bus := newTestCPU(0x3E, 0x05, 0xE0, 0x0F, 0xE0, 0xFF, 0xF0, 0x0F)
+	for range 4 {
+		cpu.Step()
+	}
+	if cpu.interrupt_flag != 0x05 || cpu.interrupt_enable != 0x05 {
+		t.Errorf("failed : expected ie and if : 0x05 got : 0x%02X 0x%02X", cpu.interrupt_enable, cpu.interrupt_flag)
+	}
+	if bus[0xFF0F] != 0 || bus[0xFFFF] != 0 {
+		t.Errorf("failed : ie and if should not reach the bus")
+	}
+	// the upper 3 bits of IF always read as 1
+	if cpu.regs.a != 0xE5 {
+		t.Errorf("failed : if expected : 0xE5 got : 0x%02X", cpu.regs.a)
+	}
+}
@@ -0,0 +1,91 @@
+package cpu
+
+// the condition a jump, call or return checks before doing anything
+type JumpTest uint8
+
+const (
+	Always JumpTest = iota
+	NotZero
+	Zero
+	NotCarry
+	Carry
+)
+
+func (cpu *CPU) testJump(test JumpTest) bool {
+	var ok bool
+	switch test {
+	case Always:
+		{
+			ok = true
+		}
+	case NotZero:
+		{
+			ok = !cpu.regs.f.zero
+		}
+	case Zero:
+		{
+			ok = cpu.regs.f.zero
+		}
+	case NotCarry:
+		{
+			ok = !cpu.regs.f.carry
+		}
+	case Carry:
+		{
+			ok = cpu.regs.f.carry
+		}
+	}
+	return ok
+}
+
+// JP a16 and JP cc,a16
+func (cpu *CPU) jump(test JumpTest) {
+	if cpu.testJump(test) {
+		cpu.regs.pc = cpu.imm
+		cpu.tick()
+	}
+}
+
+// JP (HL), it really is JP HL because it never reads memory
+func (cpu *CPU) jumpHL() {
+	cpu.regs.pc = cpu.regs.getHL()
+}
+
+// JR r8 and JR cc,r8, the offset is signed and relative to the instruction
+// that comes after the jump because pc was already moved past the operand
+func (cpu *CPU) jumpRelative(test JumpTest) {
+	if cpu.testJump(test) {
+		cpu.regs.pc += uint16(int8(uint8(cpu.imm)))
+		cpu.tick()
+	}
+}
+
+// CALL a16 and CALL cc,a16, pushes the address of the next instruction so RET
+// can come back to it
+func (cpu *CPU) call(test JumpTest) {
+	if cpu.testJump(test) {
+		cpu.tick()
+		cpu.push(cpu.regs.pc)
+		cpu.regs.pc = cpu.imm
+	}
+}
+
+// RET and RET cc, the conditional one spends an extra m-cycle checking the
+// flags so it ends up being slower than RET when it returns
+func (cpu *CPU) ret(test JumpTest) {
+	if test != Always {
+		cpu.tick()
+	}
+	if cpu.testJump(test) {
+		cpu.regs.pc = cpu.pop()
+		cpu.tick()
+	}
+}
+
+// RST n, a one byte CALL to one of the eight fixed vectors at the start of
+// the rom (0x00, 0x08, 0x10 ... 0x38)
+func (cpu *CPU) rst(vector uint8) {
+	cpu.tick()
+	cpu.push(cpu.regs.pc)
+	cpu.regs.pc = uint16(vector)
+}
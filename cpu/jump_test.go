@@ -0,0 +1,178 @@
+package cpu
+
+import "testing"
+
+func TestJumps(t *testing.T) {
+	type test struct {
+		title    string
+		program  []uint8
+		regs     Registers
+		memory   map[uint16]uint8
+		expected Registers
+		written  map[uint16]uint8
+		cycles   uint8
+	}
+
+	zero := FlagsRegister{zero: true}
+	carry := FlagsRegister{carry: true}
+
+	tests := []test{
+		{
+			title:    "jp a16",
+			program:  []uint8{0xC3, 0x50, 0x01},
+			expected: Registers{pc: 0x0150},
+			cycles:   16,
+		},
+		{
+			title:    "jp nz,a16 taken",
+			program:  []uint8{0xC2, 0x00, 0x20},
+			expected: Registers{pc: 0x2000},
+			cycles:   16,
+		},
+		{
+			title:    "jp nz,a16 not taken",
+			program:  []uint8{0xC2, 0x00, 0x20},
+			regs:     Registers{f: zero},
+			expected: Registers{pc: 3, f: zero},
+			cycles:   12,
+		},
+		{
+			title:    "jp c,a16 taken",
+			program:  []uint8{0xDA, 0x00, 0x20},
+			regs:     Registers{f: carry},
+			expected: Registers{pc: 0x2000, f: carry},
+			cycles:   16,
+		},
+		{
+			title:    "jp (hl)",
+			program:  []uint8{0xE9},
+			regs:     Registers{h: 0x12, l: 0x34},
+			expected: Registers{h: 0x12, l: 0x34, pc: 0x1234},
+			cycles:   4,
+		},
+		{
+			title:    "jr r8 forwards",
+			program:  []uint8{0x18, 0x05},
+			expected: Registers{pc: 7},
+			cycles:   12,
+		},
+		{
+			// 0xFE is -2 so it jumps back to itself
+			title:    "jr r8 backwards",
+			program:  []uint8{0x18, 0xFE},
+			expected: Registers{pc: 0},
+			cycles:   12,
+		},
+		{
+			title:    "jr z,r8 not taken",
+			program:  []uint8{0x28, 0x10},
+			expected: Registers{pc: 2},
+			cycles:   8,
+		},
+		{
+			title:    "jr nc,r8 taken",
+			program:  []uint8{0x30, 0x10},
+			expected: Registers{pc: 0x12},
+			cycles:   12,
+		},
+		{
+			title:    "call a16",
+			program:  []uint8{0xCD, 0x00, 0x40},
+			regs:     Registers{sp: 0xFFFE},
+			expected: Registers{pc: 0x4000, sp: 0xFFFC},
+			written:  map[uint16]uint8{0xFFFD: 0x00, 0xFFFC: 0x03},
+			cycles:   24,
+		},
+		{
+			title:    "call z,a16 not taken",
+			program:  []uint8{0xCC, 0x00, 0x40},
+			regs:     Registers{sp: 0xFFFE},
+			expected: Registers{pc: 3, sp: 0xFFFE},
+			cycles:   12,
+		},
+		{
+			title:    "ret",
+			program:  []uint8{0xC9},
+			regs:     Registers{sp: 0xFFFC},
+			memory:   map[uint16]uint8{0xFFFC: 0x34, 0xFFFD: 0x12},
+			expected: Registers{pc: 0x1234, sp: 0xFFFE},
+			cycles:   16,
+		},
+		{
+			title:    "ret nc taken",
+			program:  []uint8{0xD0},
+			regs:     Registers{sp: 0xFFFC},
+			memory:   map[uint16]uint8{0xFFFC: 0x34, 0xFFFD: 0x12},
+			expected: Registers{pc: 0x1234, sp: 0xFFFE},
+			cycles:   20,
+		},
+		{
+			title:    "ret c not taken",
+			program:  []uint8{0xD8},
+			regs:     Registers{sp: 0xFFFC},
+			expected: Registers{pc: 1, sp: 0xFFFC},
+			cycles:   8,
+		},
+		{
+			title:    "rst 38h",
+			program:  []uint8{0x00, 0xFF},
+			regs:     Registers{pc: 1, sp: 0xFFFE},
+			expected: Registers{pc: 0x38, sp: 0xFFFC},
+			written:  map[uint16]uint8{0xFFFD: 0x00, 0xFFFC: 0x02},
+			cycles:   16,
+		},
+	}
+
+	for _, unit_test := range tests {
+		cpu, bus := newTestCPU(unit_test.program...)
+		cpu.regs = unit_test.regs
+		for address, value := range unit_test.memory {
+			bus[address] = value
+		}
+		cycles := cpu.Step()
+		success := true
+		if cpu.regs != unit_test.expected {
+			t.Errorf("failed : %s expected : %v got : %v", unit_test.title, &unit_test.expected, &cpu.regs)
+			success = false
+		}
+		for address, value := range unit_test.written {
+			if bus[address] != value {
+				t.Errorf("failed : %s expected (0x%04X) : 0x%02X got : 0x%02X", unit_test.title, address, value, bus[address])
+				success = false
+			}
+		}
+		if cycles != unit_test.cycles {
+			t.Errorf("failed : %s expected : %d cycles got : %d", unit_test.title, unit_test.cycles, cycles)
+			success = false
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
+
+func TestCallAndReturn(t *testing.T) {
+	// 0x0000 LD B,3
+	// 0x0002 CALL 0x0010
+	// 0x0005 JR -2 (waits here forever)
+	// 0x0010 DEC B
+	// 0x0011 JR NZ,-3
+	// 0x0013 RET
+	cpu, bus := newTestCPU(0x06, 0x03, 0xCD, 0x10, 0x00, 0x18, 0xFE)
+	copy(bus[0x10:], []uint8{0x05, 0x20, 0xFD, 0xC9})
+	cpu.regs.sp = 0xFFFE
+
+	for range 9 {
+		cpu.Step()
+	}
+
+	if cpu.regs.b != 0 {
+		t.Errorf("failed : loop expected b : 0 got : %d", cpu.regs.b)
+	}
+	if cpu.regs.pc != 0x0005 {
+		t.Errorf("failed : return expected pc : 0x0005 got : 0x%04X", cpu.regs.pc)
+	}
+	if cpu.regs.sp != 0xFFFE {
+		t.Errorf("failed : return expected sp : 0xFFFE got : 0x%04X", cpu.regs.sp)
+	}
+}
@@ -0,0 +1,9 @@
+package cpu
+
+// copies the value of the source into the target, it covers every 8 bit
+// load: LD r,r' LD r,d8 LD r,(HL) LD (HL),r LD A,(BC) LD (a16),A LDH and the
+// (HL+) (HL-) ones, the flags are never touched
+func (cpu *CPU) load(target, source ArithmeticTarget) {
+	value := cpu.getRegisterValueByTarget(source)
+	cpu.setRegisterByTarget(target, value)
+}
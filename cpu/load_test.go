@@ -0,0 +1,251 @@
+package cpu
+
+import "testing"
+
+func TestLoadRegisters(t *testing.T) {
+
+	type load_test struct {
+		test
+		source ArithmeticTarget
+	}
+
+	cpu := CPU{}
+	tests := []load_test{
+		{
+			test: test{
+				regs: Registers{
+					b: 42,
+				},
+				instruction: LD,
+				title:       "ld a,b",
+				expected:    42,
+				result:      &cpu.regs.a,
+				target:      A,
+			},
+			source: B,
+		},
+		{
+			test: test{
+				regs: Registers{
+					a: 7,
+					l: 3,
+				},
+				instruction: LD,
+				title:       "ld l,a",
+				expected:    7,
+				result:      &cpu.regs.l,
+				target:      L,
+			},
+			source: A,
+		},
+		{
+			test: test{
+				regs: Registers{
+					e: 0xFF,
+					f: FlagsRegister{
+						zero:  true,
+						carry: true,
+					},
+				},
+				instruction: LD,
+				title:       "ld d,e keeps the flags",
+				expected:    0xFF,
+				result:      &cpu.regs.d,
+				flags_result: FlagsRegister{
+					zero:  true,
+					carry: true,
+				},
+				target: D,
+			},
+			source: E,
+		},
+	}
+
+	for _, unit_test := range tests {
+		unit_test.updateRegisters(&cpu)
+		cpu.execute(operation{instruction: unit_test.instruction, target: unit_test.target, source: unit_test.source})
+		success := true
+		if *unit_test.result != unit_test.expected {
+			t.Errorf(
+				"failed : %s expected : %v got : %v",
+				unit_test.title,
+				unit_test.expected,
+				*unit_test.result,
+			)
+			success = false
+		}
+
+		flags_comp := CompareFlagsRegister(cpu.regs.f, unit_test.flags_result)
+		if flags_comp != nil {
+			t.Errorf(
+				"failed : %s expected : %v got : %v\n",
+				unit_test.title,
+				unit_test.flags_result,
+				cpu.regs.f,
+			)
+			t.Error(flags_comp.Error())
+			success = false
+		}
+
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
+
+func TestLoadMemory(t *testing.T) {
+	type test struct {
+		title    string
+		program  []uint8
+		regs     Registers
+		memory   map[uint16]uint8
+		expected Registers
+		written  map[uint16]uint8
+		cycles   uint8
+	}
+
+	tests := []test{
+		{
+			title:    "ld b,d8",
+			program:  []uint8{0x06, 0x99},
+			expected: Registers{b: 0x99, pc: 2},
+			cycles:   8,
+		},
+		{
+			title:    "ld c,(hl)",
+			program:  []uint8{0x4E},
+			regs:     Registers{h: 0xC0, l: 0x10},
+			memory:   map[uint16]uint8{0xC010: 0x55},
+			expected: Registers{c: 0x55, h: 0xC0, l: 0x10, pc: 1},
+			cycles:   8,
+		},
+		{
+			title:    "ld (hl),e",
+			program:  []uint8{0x73},
+			regs:     Registers{e: 0x12, h: 0xC0},
+			expected: Registers{e: 0x12, h: 0xC0, pc: 1},
+			written:  map[uint16]uint8{0xC000: 0x12},
+			cycles:   8,
+		},
+		{
+			title:    "ld (hl),d8",
+			program:  []uint8{0x36, 0xAB},
+			regs:     Registers{h: 0xC0, l: 0x01},
+			expected: Registers{h: 0xC0, l: 0x01, pc: 2},
+			written:  map[uint16]uint8{0xC001: 0xAB},
+			cycles:   12,
+		},
+		{
+			title:    "ld a,(bc)",
+			program:  []uint8{0x0A},
+			regs:     Registers{b: 0xD0, c: 0x05},
+			memory:   map[uint16]uint8{0xD005: 0x77},
+			expected: Registers{a: 0x77, b: 0xD0, c: 0x05, pc: 1},
+			cycles:   8,
+		},
+		{
+			title:    "ld (de),a",
+			program:  []uint8{0x12},
+			regs:     Registers{a: 0x31, d: 0xD1, e: 0x02},
+			expected: Registers{a: 0x31, d: 0xD1, e: 0x02, pc: 1},
+			written:  map[uint16]uint8{0xD102: 0x31},
+			cycles:   8,
+		},
+		{
+			title:    "ld (hl+),a",
+			program:  []uint8{0x22},
+			regs:     Registers{a: 0x01, h: 0xC0, l: 0xFF},
+			expected: Registers{a: 0x01, h: 0xC1, l: 0x00, pc: 1},
+			written:  map[uint16]uint8{0xC0FF: 0x01},
+			cycles:   8,
+		},
+		{
+			title:    "ld a,(hl-)",
+			program:  []uint8{0x3A},
+			regs:     Registers{h: 0xC1, l: 0x00},
+			memory:   map[uint16]uint8{0xC100: 0x42},
+			expected: Registers{a: 0x42, h: 0xC0, l: 0xFF, pc: 1},
+			cycles:   8,
+		},
+		{
+			// hl points to the instruction itself
+			title:    "ld a,(hl-) wraps",
+			program:  []uint8{0x3A},
+			expected: Registers{a: 0x3A, h: 0xFF, l: 0xFF, pc: 1},
+			cycles:   8,
+		},
+		{
+			title:    "ld (a16),a",
+			program:  []uint8{0xEA, 0x34, 0xC2},
+			regs:     Registers{a: 0x66},
+			expected: Registers{a: 0x66, pc: 3},
+			written:  map[uint16]uint8{0xC234: 0x66},
+			cycles:   16,
+		},
+		{
+			title:    "ld a,(a16)",
+			program:  []uint8{0xFA, 0x00, 0xD0},
+			memory:   map[uint16]uint8{0xD000: 0x13},
+			expected: Registers{a: 0x13, pc: 3},
+			cycles:   16,
+		},
+		{
+			title:    "ldh (a8),a",
+			program:  []uint8{0xE0, 0x80},
+			regs:     Registers{a: 0x5C},
+			expected: Registers{a: 0x5C, pc: 2},
+			written:  map[uint16]uint8{0xFF80: 0x5C},
+			cycles:   12,
+		},
+		{
+			title:    "ldh a,(a8)",
+			program:  []uint8{0xF0, 0x44},
+			memory:   map[uint16]uint8{0xFF44: 0x90},
+			expected: Registers{a: 0x90, pc: 2},
+			cycles:   12,
+		},
+		{
+			title:    "ld (c),a",
+			program:  []uint8{0xE2},
+			regs:     Registers{a: 0x08, c: 0x47},
+			expected: Registers{a: 0x08, c: 0x47, pc: 1},
+			written:  map[uint16]uint8{0xFF47: 0x08},
+			cycles:   8,
+		},
+		{
+			title:    "ld a,(c)",
+			program:  []uint8{0xF2},
+			regs:     Registers{c: 0x00},
+			memory:   map[uint16]uint8{0xFF00: 0xCF},
+			expected: Registers{a: 0xCF, pc: 1},
+			cycles:   8,
+		},
+	}
+
+	for _, unit_test := range tests {
+		cpu, bus := newTestCPU(unit_test.program...)
+		cpu.regs = unit_test.regs
+		for address, value := range unit_test.memory {
+			bus[address] = value
+		}
+		cycles := cpu.Step()
+		success := true
+		if cpu.regs != unit_test.expected {
+			t.Errorf("failed : %s expected : %v got : %v", unit_test.title, &unit_test.expected, &cpu.regs)
+			success = false
+		}
+		for address, value := range unit_test.written {
+			if bus[address] != value {
+				t.Errorf("failed : %s expected (0x%04X) : 0x%02X got : 0x%02X", unit_test.title, address, value, bus[address])
+				success = false
+			}
+		}
+		if cycles != unit_test.cycles {
+			t.Errorf("failed : %s expected : %d cycles got : %d", unit_test.title, unit_test.cycles, cycles)
+			success = false
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
@@ -0,0 +1,433 @@
+package cpu
+
+// MicroCPU runs the same instructions as CPU but one m-cycle at a time, every
+// instruction is broken into the m-cycles gbctr describes and each of them
+// does at most one bus access, so the reads and writes land on the exact
+// cycle they do on the hardware and whatever runs between two Ticks (ppu,
+// timer, dma...) sees memory in the right state
+// https://gekkio.fi/files/gb-docs/gbctr.pdf
+type MicroCPU struct {
+	CPU
+	// the m-cycles the current instruction still has to run, the first one
+	// runs on the next Tick
+	queue  []microOp
+	buffer [8]microOp
+	// the work that doesnt need the bus (alu, register loads...) is done once
+	// the last m-cycle of the instruction is over, on the hardware that
+	// happens while the next opcode is being fetched
+	finish microOp
+	// the instruction being run, operands read from memory end up in cpu.imm
+	op operation
+	// the 16 bit value being pushed by PUSH, CALL, RST and the interrupts
+	word uint16
+	// an instruction is in flight, the queue can be empty and still have the
+	// finish pending
+	running bool
+	// EI was run right before the current instruction
+	enable_ime bool
+}
+
+// one m-cycle of an instruction, it has to spend exactly one m-cycle, either
+// with a bus access or with a tick
+type microOp func(cpu *MicroCPU)
+
+func NewMicro(bus Bus) *MicroCPU {
+	return &MicroCPU{CPU: CPU{bus: bus}}
+}
+
+// Tick runs one m-cycle, the first one of every instruction fetches its
+// opcode
+func (cpu *MicroCPU) Tick() {
+	if !cpu.running {
+		cpu.begin()
+	} else {
+		next := cpu.queue[0]
+		cpu.queue = cpu.queue[1:]
+		next(cpu)
+	}
+	if cpu.running && len(cpu.queue) == 0 {
+		cpu.complete()
+	}
+}
+
+// Step runs m-cycles until the current instruction is done and returns the
+// t-cycles they took, it behaves like CPU.Step so both cores can be used the
+// same way
+func (cpu *MicroCPU) Step() uint8 {
+	start := cpu.cycles
+	cpu.Tick()
+	for cpu.running {
+		cpu.Tick()
+	}
+	return uint8(cpu.cycles - start)
+}
+
+// Reset puts the cpu back in the state it has when it is turned on, the bus,
+// the connected components and the cycle count are kept
+func (cpu *MicroCPU) Reset() {
+	cpu.CPU.Reset()
+	*cpu = MicroCPU{CPU: cpu.CPU}
+}
+
+func (cpu *MicroCPU) then(ops ...microOp) {
+	cpu.queue = append(cpu.queue, ops...)
+}
+
+// the first m-cycle, it either idles (halt, stop, locked), starts an
+// interrupt or fetches and decodes the next opcode
+func (cpu *MicroCPU) begin() {
+	if cpu.stopped || cpu.locked != nil {
+		cpu.tick()
+		return
+	}
+	if cpu.halted {
+		if cpu.pendingInterrupts() == 0 {
+			cpu.tick()
+			return
+		}
+		cpu.halted = false
+	}
+
+	cpu.running = true
+	cpu.queue = cpu.buffer[:0]
+	cpu.finish = nil
+	if cpu.ime && cpu.pendingInterrupts() != 0 {
+		cpu.dispatch()
+		return
+	}
+	// EI only takes effect once the instruction after it is done
+	cpu.enable_ime = cpu.ime_scheduled
+
+	address := cpu.regs.pc
+	code := cpu.fetch()
+	op := opcodes[code]
+	if op.instruction == ILLEGAL {
+		cpu.lock(code, address)
+		cpu.running = false
+		return
+	}
+	cpu.decode(op)
+}
+
+func (cpu *MicroCPU) complete() {
+	if cpu.finish != nil {
+		cpu.finish(cpu)
+		cpu.finish = nil
+	}
+	if cpu.enable_ime && cpu.ime_scheduled {
+		cpu.ime = true
+		cpu.ime_scheduled = false
+	}
+	cpu.enable_ime = false
+	cpu.running = false
+}
+
+// queues the m-cycles that come after the opcode fetch
+func (cpu *MicroCPU) decode(op opcode) {
+	cpu.op = op.operation
+	switch op.length {
+	case 2:
+		{
+			cpu.then((*MicroCPU).readLow)
+		}
+	case 3:
+		{
+			cpu.then((*MicroCPU).readLow, (*MicroCPU).readHigh)
+		}
+	}
+
+	switch op.instruction {
+	case PREFIX:
+		{
+			cpu.then((*MicroCPU).readPrefixed)
+		}
+	case LD:
+		{
+			cpu.decodeLoad()
+		}
+	case LD16:
+		{
+			switch {
+			case cpu.op.target == A16I:
+				{
+					cpu.then((*MicroCPU).writeSPLow, (*MicroCPU).writeSPHigh)
+				}
+			case cpu.op.source == HL:
+				{
+					cpu.then((*MicroCPU).executeInternal)
+				}
+			default:
+				{
+					cpu.finish = (*MicroCPU).run
+				}
+			}
+		}
+	case INC16, DEC16, ADDHL, LDHL:
+		{
+			cpu.then((*MicroCPU).executeInternal)
+		}
+	case ADDSP:
+		{
+			cpu.then((*MicroCPU).idle, (*MicroCPU).idle)
+			cpu.finish = (*MicroCPU).setSP
+		}
+	case JP:
+		{
+			// the condition is checked once the address is read
+			cpu.queue[1] = (*MicroCPU).readHighThenJump
+		}
+	case JR:
+		{
+			cpu.queue[0] = (*MicroCPU).readLowThenJumpRelative
+		}
+	case CALL:
+		{
+			cpu.queue[1] = (*MicroCPU).readHighThenCall
+		}
+	case RET, RETI:
+		{
+			if cpu.op.test != Always {
+				cpu.then((*MicroCPU).checkReturn)
+			} else {
+				cpu.then((*MicroCPU).popLow, (*MicroCPU).popHigh, (*MicroCPU).returnToAddress)
+			}
+			if op.instruction == RETI {
+				cpu.finish = (*MicroCPU).enableInterrupts
+			}
+		}
+	case RST:
+		{
+			cpu.word = cpu.regs.pc
+			cpu.imm = uint16(cpu.op.vector)
+			cpu.then((*MicroCPU).idle, (*MicroCPU).pushHigh, (*MicroCPU).pushLow)
+			cpu.finish = (*MicroCPU).jumpToImmediate
+		}
+	case PUSH:
+		{
+			cpu.word = cpu.getWideByTarget(cpu.op.target)
+			cpu.then((*MicroCPU).idle, (*MicroCPU).pushHigh, (*MicroCPU).pushLow)
+		}
+	case POP:
+		{
+			cpu.then((*MicroCPU).popLow, (*MicroCPU).popHigh)
+			cpu.finish = (*MicroCPU).setWideTarget
+		}
+	default:
+		{
+			cpu.decodeOperand()
+		}
+	}
+}
+
+// the 8 bit handlers, (hl) is read in its own m-cycle and the ones that
+// modify it write it back in the next one
+func (cpu *MicroCPU) decodeOperand() {
+	if cpu.op.target != HLI {
+		cpu.finish = (*MicroCPU).run
+		return
+	}
+	switch cpu.op.instruction {
+	case ADD, ADC, SUB, SBC, AND, OR, XOR, CP, BIT:
+		{
+			cpu.then((*MicroCPU).readHL)
+			cpu.finish = (*MicroCPU).run
+		}
+	default:
+		{
+			cpu.then((*MicroCPU).modifyHL, (*MicroCPU).writeHL)
+		}
+	}
+	cpu.op.target = D8
+}
+
+// LD r,(rr) reads in one m-cycle and LD (rr),r writes in one, the immediates
+// were already queued by decode
+func (cpu *MicroCPU) decodeLoad() {
+	switch {
+	case isMemory(cpu.op.source):
+		{
+			cpu.then((*MicroCPU).readSource)
+			cpu.finish = (*MicroCPU).loadImmediate
+		}
+	case isMemory(cpu.op.target):
+		{
+			cpu.then((*MicroCPU).writeTarget)
+		}
+	default:
+		{
+			cpu.finish = (*MicroCPU).run
+		}
+	}
+}
+
+// the interrupt dispatch, the first m-cycle reads the opcode at pc but throws
+// it away, the interrupt to service is picked after pushing the high byte of
+// pc, if that push wrote to IE and disabled every pending interrupt the cpu
+// ends up jumping to 0x0000
+func (cpu *MicroCPU) dispatch() {
+	cpu.ime = false
+	cpu.ime_scheduled = false
+	cpu.read(cpu.regs.pc)
+	if cpu.halt_bug {
+		cpu.halt_bug = false
+		cpu.regs.pc--
+	}
+	cpu.word = cpu.regs.pc
+	cpu.then((*MicroCPU).idle, (*MicroCPU).pushHighThenPickInterrupt, (*MicroCPU).pushLow, (*MicroCPU).idle)
+	cpu.finish = (*MicroCPU).jumpToImmediate
+}
+
+func (cpu *MicroCPU) idle() {
+	cpu.tick()
+}
+
+// runs the operation once every byte it needs is in cpu.imm
+func (cpu *MicroCPU) run() {
+	cpu.op.immediate = cpu.imm
+	cpu.execute(cpu.op)
+}
+
+// INC rr, DEC rr, ADD HL,rr, LD SP,HL and LD HL,SP+r8 only spend one internal
+// m-cycle and execute already counts it
+func (cpu *MicroCPU) executeInternal() {
+	cpu.run()
+}
+
+func (cpu *MicroCPU) readLow() {
+	cpu.imm = uint16(cpu.fetch())
+}
+
+func (cpu *MicroCPU) readHigh() {
+	cpu.imm |= uint16(cpu.fetch()) << 8
+}
+
+func (cpu *MicroCPU) readPrefixed() {
+	op := cbOpcodes[cpu.fetch()]
+	cpu.op = op.operation
+	cpu.decodeOperand()
+}
+
+func (cpu *MicroCPU) readHL() {
+	cpu.imm = uint16(cpu.read(cpu.regs.getHL()))
+}
+
+// reads (hl) and runs the handler on it, the result is left in cpu.mem
+func (cpu *MicroCPU) modifyHL() {
+	cpu.readHL()
+	cpu.run()
+}
+
+func (cpu *MicroCPU) writeHL() {
+	cpu.write(cpu.regs.getHL(), uint8(cpu.mem))
+}
+
+func (cpu *MicroCPU) readSource() {
+	cpu.imm = uint16(cpu.read(cpu.getAddressByTarget(cpu.op.source)))
+}
+
+func (cpu *MicroCPU) loadImmediate() {
+	cpu.setRegisterByTarget(cpu.op.target, reg(cpu.imm))
+}
+
+func (cpu *MicroCPU) writeTarget() {
+	value := cpu.getRegisterValueByTarget(cpu.op.source)
+	cpu.write(cpu.getAddressByTarget(cpu.op.target), uint8(value))
+}
+
+func (cpu *MicroCPU) writeSPLow() {
+	cpu.write(cpu.imm, uint8(cpu.regs.sp&0xFF))
+}
+
+func (cpu *MicroCPU) writeSPHigh() {
+	cpu.write(cpu.imm+1, uint8(cpu.regs.sp>>8))
+}
+
+func (cpu *MicroCPU) setSP() {
+	cpu.regs.sp = cpu.spPlusImmediate()
+}
+
+func (cpu *MicroCPU) readHighThenJump() {
+	cpu.readHigh()
+	if cpu.testJump(cpu.op.test) {
+		cpu.then((*MicroCPU).idle)
+		cpu.finish = (*MicroCPU).jumpToImmediate
+	}
+}
+
+func (cpu *MicroCPU) readLowThenJumpRelative() {
+	cpu.readLow()
+	if cpu.testJump(cpu.op.test) {
+		cpu.then((*MicroCPU).idle)
+		cpu.imm = cpu.regs.pc + uint16(int8(uint8(cpu.imm)))
+		cpu.finish = (*MicroCPU).jumpToImmediate
+	}
+}
+
+func (cpu *MicroCPU) readHighThenCall() {
+	cpu.readHigh()
+	if cpu.testJump(cpu.op.test) {
+		cpu.word = cpu.regs.pc
+		cpu.then((*MicroCPU).idle, (*MicroCPU).pushHigh, (*MicroCPU).pushLow)
+		cpu.finish = (*MicroCPU).jumpToImmediate
+	}
+}
+
+// RET cc spends its second m-cycle checking the flags
+func (cpu *MicroCPU) checkReturn() {
+	cpu.tick()
+	if cpu.testJump(cpu.op.test) {
+		cpu.then((*MicroCPU).popLow, (*MicroCPU).popHigh, (*MicroCPU).returnToAddress)
+	}
+}
+
+func (cpu *MicroCPU) returnToAddress() {
+	cpu.tick()
+	cpu.regs.pc = cpu.imm
+}
+
+func (cpu *MicroCPU) jumpToImmediate() {
+	cpu.regs.pc = cpu.imm
+}
+
+func (cpu *MicroCPU) enableInterrupts() {
+	cpu.ime = true
+}
+
+func (cpu *MicroCPU) pushHigh() {
+	cpu.regs.sp--
+	cpu.write(cpu.regs.sp, uint8(cpu.word>>8))
+}
+
+func (cpu *MicroCPU) pushLow() {
+	cpu.regs.sp--
+	cpu.write(cpu.regs.sp, uint8(cpu.word&0xFF))
+}
+
+func (cpu *MicroCPU) pushHighThenPickInterrupt() {
+	cpu.pushHigh()
+	cpu.imm = 0x0000
+	pending := cpu.pendingInterrupts()
+	if pending == 0 {
+		return
+	}
+	var interrupt Interrupt
+	for pending&(1<<interrupt) == 0 {
+		interrupt++
+	}
+	cpu.interrupt_flag &^= 1 << interrupt
+	cpu.imm = INTERRUPT_VECTOR + 8*uint16(interrupt)
+}
+
+func (cpu *MicroCPU) popLow() {
+	cpu.imm = uint16(cpu.read(cpu.regs.sp))
+	cpu.regs.sp++
+}
+
+func (cpu *MicroCPU) popHigh() {
+	cpu.imm |= uint16(cpu.read(cpu.regs.sp)) << 8
+	cpu.regs.sp++
+}
+
+func (cpu *MicroCPU) setWideTarget() {
+	cpu.setWideByTarget(cpu.op.target, cpu.imm)
+}
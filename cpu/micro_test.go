@@ -0,0 +1,288 @@
+package cpu
+
+import "testing"
+
+func newTestMicroCPU(program ...uint8) (*MicroCPU, *testBus) {
+	bus := &testBus{}
+	copy(bus[:], program)
+	return NewMicro(bus), bus
+}
+
+var _ Core = (*CPU)(nil)
+var _ Core = (*MicroCPU)(nil)
+
+// both cores start from the same state and have to end up with the same
+// registers, memory and cycles, every Tick of the micro core has to be
+// exactly one m-cycle
+func TestMicroMatchesCPU(t *testing.T) {
+	type test struct {
+		program []uint8
+		flags   uint8
+	}
+
+	tests := []test{}
+	for code, op := range opcodes {
+		if op.instruction == PREFIX || op.instruction == ILLEGAL {
+			continue
+		}
+		program := []uint8{uint8(code), 0x34, 0x12}
+		tests = append(tests, test{program: program, flags: 0x00}, test{program: program, flags: 0xF0})
+	}
+	for code := range cbOpcodes {
+		tests = append(tests, test{program: []uint8{0xCB, uint8(code)}, flags: 0x50})
+	}
+
+	for _, unit_test := range tests {
+		regs := Registers{
+			a:  0x3C,
+			b:  0x01,
+			c:  0x80,
+			d:  0xC1,
+			e:  0x23,
+			h:  0xC2,
+			l:  0x45,
+			f:  toFlagReg(unit_test.flags),
+			sp: 0xD000,
+		}
+		reference, reference_bus := newTestCPU(unit_test.program...)
+		micro, micro_bus := newTestMicroCPU(unit_test.program...)
+		for _, bus := range []*testBus{reference_bus, micro_bus} {
+			bus[0xC123] = 0x99
+			bus[0xC245] = 0x0F
+			bus[0xCFFF] = 0x56
+			bus[0xD000] = 0x78
+			bus[0xD001] = 0x9A
+		}
+		reference.regs = regs
+		micro.regs = regs
+
+		expected := reference.Step()
+		cycles := uint8(0)
+		micro.Tick()
+		cycles += M_CYCLE
+		for micro.running {
+			before := micro.Cycles()
+			micro.Tick()
+			if micro.Cycles()-before != M_CYCLE {
+				t.Errorf("failed : % X tick took %d cycles", unit_test.program, micro.Cycles()-before)
+			}
+			cycles += M_CYCLE
+		}
+
+		if cycles != expected {
+			t.Errorf("failed : % X flags 0x%02X expected : %d cycles got : %d", unit_test.program, unit_test.flags, expected, cycles)
+		}
+		if micro.regs != reference.regs {
+			t.Errorf("failed : % X flags 0x%02X expected : %v got : %v", unit_test.program, unit_test.flags, &reference.regs, &micro.regs)
+		}
+		if *micro_bus != *reference_bus {
+			t.Errorf("failed : % X flags 0x%02X memory doesnt match", unit_test.program, unit_test.flags)
+		}
+		if micro.ime != reference.ime || micro.ime_scheduled != reference.ime_scheduled || micro.halted != reference.halted || micro.stopped != reference.stopped {
+			t.Errorf("failed : % X flags 0x%02X cpu state doesnt match", unit_test.program, unit_test.flags)
+		}
+	}
+}
+
+type access struct {
+	m_cycle uint64
+	address uint16
+	write   bool
+}
+
+// remembers in which m-cycle every access happened
+type recordingBus struct {
+	testBus
+	cpu      *MicroCPU
+	accesses []access
+}
+
+func (b *recordingBus) Read(address uint16) uint8 {
+	b.accesses = append(b.accesses, access{m_cycle: b.cpu.Cycles() / M_CYCLE, address: address})
+	return b.testBus.Read(address)
+}
+
+func (b *recordingBus) Write(address uint16, value uint8) {
+	b.accesses = append(b.accesses, access{m_cycle: b.cpu.Cycles() / M_CYCLE, address: address, write: true})
+	b.testBus.Write(address, value)
+}
+
+func TestMicroAccessTiming(t *testing.T) {
+	type test struct {
+		title    string
+		program  []uint8
+		expected []access
+	}
+
+	tests := []test{
+		{
+			title:   "call a16",
+			program: []uint8{0xCD, 0x00, 0x02},
+			expected: []access{
+				{1, 0x0000, false},
+				{2, 0x0001, false},
+				{3, 0x0002, false},
+				// m-cycle 4 is internal
+				{5, 0xCFFF, true},
+				{6, 0xCFFE, true},
+			},
+		},
+		{
+			title:   "push bc",
+			program: []uint8{0xC5},
+			expected: []access{
+				{1, 0x0000, false},
+				{3, 0xCFFF, true},
+				{4, 0xCFFE, true},
+			},
+		},
+		{
+			title:   "inc (hl)",
+			program: []uint8{0x34},
+			expected: []access{
+				{1, 0x0000, false},
+				{2, 0xC000, false},
+				{3, 0xC000, true},
+			},
+		},
+		{
+			title:   "ld (a16),sp",
+			program: []uint8{0x08, 0x00, 0xC1},
+			expected: []access{
+				{1, 0x0000, false},
+				{2, 0x0001, false},
+				{3, 0x0002, false},
+				{4, 0xC100, true},
+				{5, 0xC101, true},
+			},
+		},
+		{
+			title:   "ret",
+			program: []uint8{0xC9},
+			expected: []access{
+				{1, 0x0000, false},
+				{2, 0xD000, false},
+				{3, 0xD001, false},
+			},
+		},
+		{
+			title:   "set 0,(hl)",
+			program: []uint8{0xCB, 0xC6},
+			expected: []access{
+				{1, 0x0000, false},
+				{2, 0x0001, false},
+				{3, 0xC000, false},
+				{4, 0xC000, true},
+			},
+		},
+	}
+
+	for _, unit_test := range tests {
+		bus := &recordingBus{}
+		copy(bus.testBus[:], unit_test.program)
+		cpu := NewMicro(bus)
+		bus.cpu = cpu
+		cpu.regs.h = 0xC0
+		cpu.regs.sp = 0xD000
+		cpu.Step()
+
+		success := len(bus.accesses) == len(unit_test.expected)
+		for i := 0; success && i < len(bus.accesses); i++ {
+			success = bus.accesses[i] == unit_test.expected[i]
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		} else {
+			t.Errorf("failed : %s expected : %v got : %v", unit_test.title, unit_test.expected, bus.accesses)
+		}
+	}
+}
+
+func TestMicroInterrupts(t *testing.T) {
+	type test struct {
+		title     string
+		sp        uint16
+		enable    uint8
+		requested []Interrupt
+		pc        uint16
+	}
+
+	tests := []test{
+		{
+			title:     "vblank",
+			sp:        0xD000,
+			enable:    1 << VBlank,
+			requested: []Interrupt{VBlank},
+			pc:        0x40,
+		},
+		// sp 0x0000 makes the high byte of pc (0x02) go to IE which disables
+		// vblank right before the interrupt is picked
+		{
+			title:     "ie push cancels the dispatch",
+			sp:        0x0000,
+			enable:    1 << VBlank,
+			requested: []Interrupt{VBlank},
+			pc:        0x0000,
+		},
+		{
+			title:     "ie push picks another interrupt",
+			sp:        0x0000,
+			enable:    1<<VBlank | 1<<LCDStat,
+			requested: []Interrupt{VBlank, LCDStat},
+			pc:        0x48,
+		},
+	}
+
+	for _, unit_test := range tests {
+		cpu, _ := newTestMicroCPU()
+		cpu.regs.pc = 0x0200
+		cpu.regs.sp = unit_test.sp
+		cpu.ime = true
+		cpu.interrupt_enable = unit_test.enable
+		for _, interrupt := range unit_test.requested {
+			cpu.RequestInterrupt(interrupt)
+		}
+		cycles := cpu.Step()
+		if cycles != 20 || cpu.regs.pc != unit_test.pc || cpu.ime {
+			t.Errorf("failed : %s expected pc : 0x%04X got : 0x%04X after %d cycles", unit_test.title, unit_test.pc, cpu.regs.pc, cycles)
+		} else {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
+
+func TestMicroEIDelay(t *testing.T) {
+	// EI, NOP, NOP
+	cpu, _ := newTestMicroCPU(0xFB, 0x00, 0x00)
+	cpu.regs.sp = 0xFFFE
+	cpu.interrupt_enable = 0x1F
+	cpu.RequestInterrupt(Timer)
+	cpu.Step()
+	cpu.Step()
+	if cpu.regs.pc != 2 || !cpu.ime {
+		t.Errorf("failed : ei expected the nop to run before the interrupt, pc : 0x%04X", cpu.regs.pc)
+	}
+	cpu.Step()
+	if cpu.regs.pc != 0x50 {
+		t.Errorf("failed : ei expected pc : 0x0050 got : 0x%04X", cpu.regs.pc)
+	}
+}
+
+func TestMicroHaltAndLock(t *testing.T) {
+	// HALT, illegal
+	cpu, _ := newTestMicroCPU(0x76, 0xD3)
+	cpu.interrupt_enable = 1 << Timer
+	cpu.Step()
+	if cycles := cpu.Step(); cycles != 4 || !cpu.halted {
+		t.Errorf("failed : halt expected the cpu to idle")
+	}
+	cpu.RequestInterrupt(Timer)
+	cpu.Step()
+	if cpu.Err() == nil || cpu.regs.pc != 2 {
+		t.Errorf("failed : expected the illegal opcode to lock the cpu")
+	}
+	cpu.Reset()
+	if cpu.Err() != nil || cpu.running || cpu.regs.pc != 0 {
+		t.Errorf("failed : reset expected a fresh cpu")
+	}
+}
@@ -0,0 +1,361 @@
+package cpu
+
+import "fmt"
+
+// describes how an opcode gets executed, length counts the opcode byte
+// plus its immediate operands and cycles are t-cycles, conditional jumps
+// take branch_cycles instead when the condition holds, Step counts the cycles
+// as they happen so these are what the hardware is documented to take
+type opcode struct {
+	mnemonic string
+	operation
+	length        uint16
+	cycles        uint8
+	branch_cycles uint8
+}
+
+// base opcode table, the ILLEGAL entries are the opcodes that dont exist on
+// the sm83, running one of them locks the cpu up
+// https://gbdev.io/gb-opcodes/optables/
+var opcodes = [256]opcode{
+	0x00: {mnemonic: "NOP", operation: operation{instruction: NOP}, length: 1, cycles: 4},
+	0x01: {mnemonic: "LD BC,d16", operation: operation{instruction: LD16, target: BC, source: D16}, length: 3, cycles: 12},
+	0x02: {mnemonic: "LD (BC),A", operation: operation{instruction: LD, target: BCI, source: A}, length: 1, cycles: 8},
+	0x03: {mnemonic: "INC BC", operation: operation{instruction: INC16, target: BC}, length: 1, cycles: 8},
+	0x04: {mnemonic: "INC B", operation: operation{instruction: INC, target: B}, length: 1, cycles: 4},
+	0x05: {mnemonic: "DEC B", operation: operation{instruction: DEC, target: B}, length: 1, cycles: 4},
+	0x06: {mnemonic: "LD B,d8", operation: operation{instruction: LD, target: B, source: D8}, length: 2, cycles: 8},
+	0x07: {mnemonic: "RLCA", operation: operation{instruction: RLCA}, length: 1, cycles: 4},
+	0x08: {mnemonic: "LD (a16),SP", operation: operation{instruction: LD16, target: A16I, source: SP}, length: 3, cycles: 20},
+	0x09: {mnemonic: "ADD HL,BC", operation: operation{instruction: ADDHL, target: BC}, length: 1, cycles: 8},
+	0x0A: {mnemonic: "LD A,(BC)", operation: operation{instruction: LD, target: A, source: BCI}, length: 1, cycles: 8},
+	0x0B: {mnemonic: "DEC BC", operation: operation{instruction: DEC16, target: BC}, length: 1, cycles: 8},
+	0x0C: {mnemonic: "INC C", operation: operation{instruction: INC, target: C}, length: 1, cycles: 4},
+	0x0D: {mnemonic: "DEC C", operation: operation{instruction: DEC, target: C}, length: 1, cycles: 4},
+	0x0E: {mnemonic: "LD C,d8", operation: operation{instruction: LD, target: C, source: D8}, length: 2, cycles: 8},
+	0x0F: {mnemonic: "RRCA", operation: operation{instruction: RRCA}, length: 1, cycles: 4},
+	0x10: {mnemonic: "STOP 0", operation: operation{instruction: STOP}, length: 2, cycles: 4},
+	0x11: {mnemonic: "LD DE,d16", operation: operation{instruction: LD16, target: DE, source: D16}, length: 3, cycles: 12},
+	0x12: {mnemonic: "LD (DE),A", operation: operation{instruction: LD, target: DEI, source: A}, length: 1, cycles: 8},
+	0x13: {mnemonic: "INC DE", operation: operation{instruction: INC16, target: DE}, length: 1, cycles: 8},
+	0x14: {mnemonic: "INC D", operation: operation{instruction: INC, target: D}, length: 1, cycles: 4},
+	0x15: {mnemonic: "DEC D", operation: operation{instruction: DEC, target: D}, length: 1, cycles: 4},
+	0x16: {mnemonic: "LD D,d8", operation: operation{instruction: LD, target: D, source: D8}, length: 2, cycles: 8},
+	0x17: {mnemonic: "RLA", operation: operation{instruction: RLA}, length: 1, cycles: 4},
+	0x18: {mnemonic: "JR r8", operation: operation{instruction: JR, test: Always}, length: 2, cycles: 12},
+	0x19: {mnemonic: "ADD HL,DE", operation: operation{instruction: ADDHL, target: DE}, length: 1, cycles: 8},
+	0x1A: {mnemonic: "LD A,(DE)", operation: operation{instruction: LD, target: A, source: DEI}, length: 1, cycles: 8},
+	0x1B: {mnemonic: "DEC DE", operation: operation{instruction: DEC16, target: DE}, length: 1, cycles: 8},
+	0x1C: {mnemonic: "INC E", operation: operation{instruction: INC, target: E}, length: 1, cycles: 4},
+	0x1D: {mnemonic: "DEC E", operation: operation{instruction: DEC, target: E}, length: 1, cycles: 4},
+	0x1E: {mnemonic: "LD E,d8", operation: operation{instruction: LD, target: E, source: D8}, length: 2, cycles: 8},
+	0x1F: {mnemonic: "RRA", operation: operation{instruction: RRA}, length: 1, cycles: 4},
+	0x20: {mnemonic: "JR NZ,r8", operation: operation{instruction: JR, test: NotZero}, length: 2, cycles: 8, branch_cycles: 12},
+	0x21: {mnemonic: "LD HL,d16", operation: operation{instruction: LD16, target: HL, source: D16}, length: 3, cycles: 12},
+	0x22: {mnemonic: "LD (HL+),A", operation: operation{instruction: LD, target: HLIP, source: A}, length: 1, cycles: 8},
+	0x23: {mnemonic: "INC HL", operation: operation{instruction: INC16, target: HL}, length: 1, cycles: 8},
+	0x24: {mnemonic: "INC H", operation: operation{instruction: INC, target: H}, length: 1, cycles: 4},
+	0x25: {mnemonic: "DEC H", operation: operation{instruction: DEC, target: H}, length: 1, cycles: 4},
+	0x26: {mnemonic: "LD H,d8", operation: operation{instruction: LD, target: H, source: D8}, length: 2, cycles: 8},
+	0x27: {mnemonic: "DAA", operation: operation{instruction: DAA}, length: 1, cycles: 4},
+	0x28: {mnemonic: "JR Z,r8", operation: operation{instruction: JR, test: Zero}, length: 2, cycles: 8, branch_cycles: 12},
+	0x29: {mnemonic: "ADD HL,HL", operation: operation{instruction: ADDHL, target: HL}, length: 1, cycles: 8},
+	0x2A: {mnemonic: "LD A,(HL+)", operation: operation{instruction: LD, target: A, source: HLIP}, length: 1, cycles: 8},
+	0x2B: {mnemonic: "DEC HL", operation: operation{instruction: DEC16, target: HL}, length: 1, cycles: 8},
+	0x2C: {mnemonic: "INC L", operation: operation{instruction: INC, target: L}, length: 1, cycles: 4},
+	0x2D: {mnemonic: "DEC L", operation: operation{instruction: DEC, target: L}, length: 1, cycles: 4},
+	0x2E: {mnemonic: "LD L,d8", operation: operation{instruction: LD, target: L, source: D8}, length: 2, cycles: 8},
+	0x2F: {mnemonic: "CPL", operation: operation{instruction: CPL}, length: 1, cycles: 4},
+	0x30: {mnemonic: "JR NC,r8", operation: operation{instruction: JR, test: NotCarry}, length: 2, cycles: 8, branch_cycles: 12},
+	0x31: {mnemonic: "LD SP,d16", operation: operation{instruction: LD16, target: SP, source: D16}, length: 3, cycles: 12},
+	0x32: {mnemonic: "LD (HL-),A", operation: operation{instruction: LD, target: HLIM, source: A}, length: 1, cycles: 8},
+	0x33: {mnemonic: "INC SP", operation: operation{instruction: INC16, target: SP}, length: 1, cycles: 8},
+	0x34: {mnemonic: "INC (HL)", operation: operation{instruction: INC, target: HLI}, length: 1, cycles: 12},
+	0x35: {mnemonic: "DEC (HL)", operation: operation{instruction: DEC, target: HLI}, length: 1, cycles: 12},
+	0x36: {mnemonic: "LD (HL),d8", operation: operation{instruction: LD, target: HLI, source: D8}, length: 2, cycles: 12},
+	0x37: {mnemonic: "SCF", operation: operation{instruction: SCF}, length: 1, cycles: 4},
+	0x38: {mnemonic: "JR C,r8", operation: operation{instruction: JR, test: Carry}, length: 2, cycles: 8, branch_cycles: 12},
+	0x39: {mnemonic: "ADD HL,SP", operation: operation{instruction: ADDHL, target: SP}, length: 1, cycles: 8},
+	0x3A: {mnemonic: "LD A,(HL-)", operation: operation{instruction: LD, target: A, source: HLIM}, length: 1, cycles: 8},
+	0x3B: {mnemonic: "DEC SP", operation: operation{instruction: DEC16, target: SP}, length: 1, cycles: 8},
+	0x3C: {mnemonic: "INC A", operation: operation{instruction: INC, target: A}, length: 1, cycles: 4},
+	0x3D: {mnemonic: "DEC A", operation: operation{instruction: DEC, target: A}, length: 1, cycles: 4},
+	0x3E: {mnemonic: "LD A,d8", operation: operation{instruction: LD, target: A, source: D8}, length: 2, cycles: 8},
+	0x3F: {mnemonic: "CCF", operation: operation{instruction: CCF}, length: 1, cycles: 4},
+	0x40: {mnemonic: "LD B,B", operation: operation{instruction: LD, target: B, source: B}, length: 1, cycles: 4},
+	0x41: {mnemonic: "LD B,C", operation: operation{instruction: LD, target: B, source: C}, length: 1, cycles: 4},
+	0x42: {mnemonic: "LD B,D", operation: operation{instruction: LD, target: B, source: D}, length: 1, cycles: 4},
+	0x43: {mnemonic: "LD B,E", operation: operation{instruction: LD, target: B, source: E}, length: 1, cycles: 4},
+	0x44: {mnemonic: "LD B,H", operation: operation{instruction: LD, target: B, source: H}, length: 1, cycles: 4},
+	0x45: {mnemonic: "LD B,L", operation: operation{instruction: LD, target: B, source: L}, length: 1, cycles: 4},
+	0x46: {mnemonic: "LD B,(HL)", operation: operation{instruction: LD, target: B, source: HLI}, length: 1, cycles: 8},
+	0x47: {mnemonic: "LD B,A", operation: operation{instruction: LD, target: B, source: A}, length: 1, cycles: 4},
+	0x48: {mnemonic: "LD C,B", operation: operation{instruction: LD, target: C, source: B}, length: 1, cycles: 4},
+	0x49: {mnemonic: "LD C,C", operation: operation{instruction: LD, target: C, source: C}, length: 1, cycles: 4},
+	0x4A: {mnemonic: "LD C,D", operation: operation{instruction: LD, target: C, source: D}, length: 1, cycles: 4},
+	0x4B: {mnemonic: "LD C,E", operation: operation{instruction: LD, target: C, source: E}, length: 1, cycles: 4},
+	0x4C: {mnemonic: "LD C,H", operation: operation{instruction: LD, target: C, source: H}, length: 1, cycles: 4},
+	0x4D: {mnemonic: "LD C,L", operation: operation{instruction: LD, target: C, source: L}, length: 1, cycles: 4},
+	0x4E: {mnemonic: "LD C,(HL)", operation: operation{instruction: LD, target: C, source: HLI}, length: 1, cycles: 8},
+	0x4F: {mnemonic: "LD C,A", operation: operation{instruction: LD, target: C, source: A}, length: 1, cycles: 4},
+	0x50: {mnemonic: "LD D,B", operation: operation{instruction: LD, target: D, source: B}, length: 1, cycles: 4},
+	0x51: {mnemonic: "LD D,C", operation: operation{instruction: LD, target: D, source: C}, length: 1, cycles: 4},
+	0x52: {mnemonic: "LD D,D", operation: operation{instruction: LD, target: D, source: D}, length: 1, cycles: 4},
+	0x53: {mnemonic: "LD D,E", operation: operation{instruction: LD, target: D, source: E}, length: 1, cycles: 4},
+	0x54: {mnemonic: "LD D,H", operation: operation{instruction: LD, target: D, source: H}, length: 1, cycles: 4},
+	0x55: {mnemonic: "LD D,L", operation: operation{instruction: LD, target: D, source: L}, length: 1, cycles: 4},
+	0x56: {mnemonic: "LD D,(HL)", operation: operation{instruction: LD, target: D, source: HLI}, length: 1, cycles: 8},
+	0x57: {mnemonic: "LD D,A", operation: operation{instruction: LD, target: D, source: A}, length: 1, cycles: 4},
+	0x58: {mnemonic: "LD E,B", operation: operation{instruction: LD, target: E, source: B}, length: 1, cycles: 4},
+	0x59: {mnemonic: "LD E,C", operation: operation{instruction: LD, target: E, source: C}, length: 1, cycles: 4},
+	0x5A: {mnemonic: "LD E,D", operation: operation{instruction: LD, target: E, source: D}, length: 1, cycles: 4},
+	0x5B: {mnemonic: "LD E,E", operation: operation{instruction: LD, target: E, source: E}, length: 1, cycles: 4},
+	0x5C: {mnemonic: "LD E,H", operation: operation{instruction: LD, target: E, source: H}, length: 1, cycles: 4},
+	0x5D: {mnemonic: "LD E,L", operation: operation{instruction: LD, target: E, source: L}, length: 1, cycles: 4},
+	0x5E: {mnemonic: "LD E,(HL)", operation: operation{instruction: LD, target: E, source: HLI}, length: 1, cycles: 8},
+	0x5F: {mnemonic: "LD E,A", operation: operation{instruction: LD, target: E, source: A}, length: 1, cycles: 4},
+	0x60: {mnemonic: "LD H,B", operation: operation{instruction: LD, target: H, source: B}, length: 1, cycles: 4},
+	0x61: {mnemonic: "LD H,C", operation: operation{instruction: LD, target: H, source: C}, length: 1, cycles: 4},
+	0x62: {mnemonic: "LD H,D", operation: operation{instruction: LD, target: H, source: D}, length: 1, cycles: 4},
+	0x63: {mnemonic: "LD H,E", operation: operation{instruction: LD, target: H, source: E}, length: 1, cycles: 4},
+	0x64: {mnemonic: "LD H,H", operation: operation{instruction: LD, target: H, source: H}, length: 1, cycles: 4},
+	0x65: {mnemonic: "LD H,L", operation: operation{instruction: LD, target: H, source: L}, length: 1, cycles: 4},
+	0x66: {mnemonic: "LD H,(HL)", operation: operation{instruction: LD, target: H, source: HLI}, length: 1, cycles: 8},
+	0x67: {mnemonic: "LD H,A", operation: operation{instruction: LD, target: H, source: A}, length: 1, cycles: 4},
+	0x68: {mnemonic: "LD L,B", operation: operation{instruction: LD, target: L, source: B}, length: 1, cycles: 4},
+	0x69: {mnemonic: "LD L,C", operation: operation{instruction: LD, target: L, source: C}, length: 1, cycles: 4},
+	0x6A: {mnemonic: "LD L,D", operation: operation{instruction: LD, target: L, source: D}, length: 1, cycles: 4},
+	0x6B: {mnemonic: "LD L,E", operation: operation{instruction: LD, target: L, source: E}, length: 1, cycles: 4},
+	0x6C: {mnemonic: "LD L,H", operation: operation{instruction: LD, target: L, source: H}, length: 1, cycles: 4},
+	0x6D: {mnemonic: "LD L,L", operation: operation{instruction: LD, target: L, source: L}, length: 1, cycles: 4},
+	0x6E: {mnemonic: "LD L,(HL)", operation: operation{instruction: LD, target: L, source: HLI}, length: 1, cycles: 8},
+	0x6F: {mnemonic: "LD L,A", operation: operation{instruction: LD, target: L, source: A}, length: 1, cycles: 4},
+	0x70: {mnemonic: "LD (HL),B", operation: operation{instruction: LD, target: HLI, source: B}, length: 1, cycles: 8},
+	0x71: {mnemonic: "LD (HL),C", operation: operation{instruction: LD, target: HLI, source: C}, length: 1, cycles: 8},
+	0x72: {mnemonic: "LD (HL),D", operation: operation{instruction: LD, target: HLI, source: D}, length: 1, cycles: 8},
+	0x73: {mnemonic: "LD (HL),E", operation: operation{instruction: LD, target: HLI, source: E}, length: 1, cycles: 8},
+	0x74: {mnemonic: "LD (HL),H", operation: operation{instruction: LD, target: HLI, source: H}, length: 1, cycles: 8},
+	0x75: {mnemonic: "LD (HL),L", operation: operation{instruction: LD, target: HLI, source: L}, length: 1, cycles: 8},
+	0x76: {mnemonic: "HALT", operation: operation{instruction: HALT}, length: 1, cycles: 4},
+	0x77: {mnemonic: "LD (HL),A", operation: operation{instruction: LD, target: HLI, source: A}, length: 1, cycles: 8},
+	0x78: {mnemonic: "LD A,B", operation: operation{instruction: LD, target: A, source: B}, length: 1, cycles: 4},
+	0x79: {mnemonic: "LD A,C", operation: operation{instruction: LD, target: A, source: C}, length: 1, cycles: 4},
+	0x7A: {mnemonic: "LD A,D", operation: operation{instruction: LD, target: A, source: D}, length: 1, cycles: 4},
+	0x7B: {mnemonic: "LD A,E", operation: operation{instruction: LD, target: A, source: E}, length: 1, cycles: 4},
+	0x7C: {mnemonic: "LD A,H", operation: operation{instruction: LD, target: A, source: H}, length: 1, cycles: 4},
+	0x7D: {mnemonic: "LD A,L", operation: operation{instruction: LD, target: A, source: L}, length: 1, cycles: 4},
+	0x7E: {mnemonic: "LD A,(HL)", operation: operation{instruction: LD, target: A, source: HLI}, length: 1, cycles: 8},
+	0x7F: {mnemonic: "LD A,A", operation: operation{instruction: LD, target: A, source: A}, length: 1, cycles: 4},
+	0x80: {mnemonic: "ADD A,B", operation: operation{instruction: ADD, target: B}, length: 1, cycles: 4},
+	0x81: {mnemonic: "ADD A,C", operation: operation{instruction: ADD, target: C}, length: 1, cycles: 4},
+	0x82: {mnemonic: "ADD A,D", operation: operation{instruction: ADD, target: D}, length: 1, cycles: 4},
+	0x83: {mnemonic: "ADD A,E", operation: operation{instruction: ADD, target: E}, length: 1, cycles: 4},
+	0x84: {mnemonic: "ADD A,H", operation: operation{instruction: ADD, target: H}, length: 1, cycles: 4},
+	0x85: {mnemonic: "ADD A,L", operation: operation{instruction: ADD, target: L}, length: 1, cycles: 4},
+	0x86: {mnemonic: "ADD A,(HL)", operation: operation{instruction: ADD, target: HLI}, length: 1, cycles: 8},
+	0x87: {mnemonic: "ADD A,A", operation: operation{instruction: ADD, target: A}, length: 1, cycles: 4},
+	0x88: {mnemonic: "ADC A,B", operation: operation{instruction: ADC, target: B}, length: 1, cycles: 4},
+	0x89: {mnemonic: "ADC A,C", operation: operation{instruction: ADC, target: C}, length: 1, cycles: 4},
+	0x8A: {mnemonic: "ADC A,D", operation: operation{instruction: ADC, target: D}, length: 1, cycles: 4},
+	0x8B: {mnemonic: "ADC A,E", operation: operation{instruction: ADC, target: E}, length: 1, cycles: 4},
+	0x8C: {mnemonic: "ADC A,H", operation: operation{instruction: ADC, target: H}, length: 1, cycles: 4},
+	0x8D: {mnemonic: "ADC A,L", operation: operation{instruction: ADC, target: L}, length: 1, cycles: 4},
+	0x8E: {mnemonic: "ADC A,(HL)", operation: operation{instruction: ADC, target: HLI}, length: 1, cycles: 8},
+	0x8F: {mnemonic: "ADC A,A", operation: operation{instruction: ADC, target: A}, length: 1, cycles: 4},
+	0x90: {mnemonic: "SUB B", operation: operation{instruction: SUB, target: B}, length: 1, cycles: 4},
+	0x91: {mnemonic: "SUB C", operation: operation{instruction: SUB, target: C}, length: 1, cycles: 4},
+	0x92: {mnemonic: "SUB D", operation: operation{instruction: SUB, target: D}, length: 1, cycles: 4},
+	0x93: {mnemonic: "SUB E", operation: operation{instruction: SUB, target: E}, length: 1, cycles: 4},
+	0x94: {mnemonic: "SUB H", operation: operation{instruction: SUB, target: H}, length: 1, cycles: 4},
+	0x95: {mnemonic: "SUB L", operation: operation{instruction: SUB, target: L}, length: 1, cycles: 4},
+	0x96: {mnemonic: "SUB (HL)", operation: operation{instruction: SUB, target: HLI}, length: 1, cycles: 8},
+	0x97: {mnemonic: "SUB A", operation: operation{instruction: SUB, target: A}, length: 1, cycles: 4},
+	0x98: {mnemonic: "SBC A,B", operation: operation{instruction: SBC, target: B}, length: 1, cycles: 4},
+	0x99: {mnemonic: "SBC A,C", operation: operation{instruction: SBC, target: C}, length: 1, cycles: 4},
+	0x9A: {mnemonic: "SBC A,D", operation: operation{instruction: SBC, target: D}, length: 1, cycles: 4},
+	0x9B: {mnemonic: "SBC A,E", operation: operation{instruction: SBC, target: E}, length: 1, cycles: 4},
+	0x9C: {mnemonic: "SBC A,H", operation: operation{instruction: SBC, target: H}, length: 1, cycles: 4},
+	0x9D: {mnemonic: "SBC A,L", operation: operation{instruction: SBC, target: L}, length: 1, cycles: 4},
+	0x9E: {mnemonic: "SBC A,(HL)", operation: operation{instruction: SBC, target: HLI}, length: 1, cycles: 8},
+	0x9F: {mnemonic: "SBC A,A", operation: operation{instruction: SBC, target: A}, length: 1, cycles: 4},
+	0xA0: {mnemonic: "AND B", operation: operation{instruction: AND, target: B}, length: 1, cycles: 4},
+	0xA1: {mnemonic: "AND C", operation: operation{instruction: AND, target: C}, length: 1, cycles: 4},
+	0xA2: {mnemonic: "AND D", operation: operation{instruction: AND, target: D}, length: 1, cycles: 4},
+	0xA3: {mnemonic: "AND E", operation: operation{instruction: AND, target: E}, length: 1, cycles: 4},
+	0xA4: {mnemonic: "AND H", operation: operation{instruction: AND, target: H}, length: 1, cycles: 4},
+	0xA5: {mnemonic: "AND L", operation: operation{instruction: AND, target: L}, length: 1, cycles: 4},
+	0xA6: {mnemonic: "AND (HL)", operation: operation{instruction: AND, target: HLI}, length: 1, cycles: 8},
+	0xA7: {mnemonic: "AND A", operation: operation{instruction: AND, target: A}, length: 1, cycles: 4},
+	0xA8: {mnemonic: "XOR B", operation: operation{instruction: XOR, target: B}, length: 1, cycles: 4},
+	0xA9: {mnemonic: "XOR C", operation: operation{instruction: XOR, target: C}, length: 1, cycles: 4},
+	0xAA: {mnemonic: "XOR D", operation: operation{instruction: XOR, target: D}, length: 1, cycles: 4},
+	0xAB: {mnemonic: "XOR E", operation: operation{instruction: XOR, target: E}, length: 1, cycles: 4},
+	0xAC: {mnemonic: "XOR H", operation: operation{instruction: XOR, target: H}, length: 1, cycles: 4},
+	0xAD: {mnemonic: "XOR L", operation: operation{instruction: XOR, target: L}, length: 1, cycles: 4},
+	0xAE: {mnemonic: "XOR (HL)", operation: operation{instruction: XOR, target: HLI}, length: 1, cycles: 8},
+	0xAF: {mnemonic: "XOR A", operation: operation{instruction: XOR, target: A}, length: 1, cycles: 4},
+	0xB0: {mnemonic: "OR B", operation: operation{instruction: OR, target: B}, length: 1, cycles: 4},
+	0xB1: {mnemonic: "OR C", operation: operation{instruction: OR, target: C}, length: 1, cycles: 4},
+	0xB2: {mnemonic: "OR D", operation: operation{instruction: OR, target: D}, length: 1, cycles: 4},
+	0xB3: {mnemonic: "OR E", operation: operation{instruction: OR, target: E}, length: 1, cycles: 4},
+	0xB4: {mnemonic: "OR H", operation: operation{instruction: OR, target: H}, length: 1, cycles: 4},
+	0xB5: {mnemonic: "OR L", operation: operation{instruction: OR, target: L}, length: 1, cycles: 4},
+	0xB6: {mnemonic: "OR (HL)", operation: operation{instruction: OR, target: HLI}, length: 1, cycles: 8},
+	0xB7: {mnemonic: "OR A", operation: operation{instruction: OR, target: A}, length: 1, cycles: 4},
+	0xB8: {mnemonic: "CP B", operation: operation{instruction: CP, target: B}, length: 1, cycles: 4},
+	0xB9: {mnemonic: "CP C", operation: operation{instruction: CP, target: C}, length: 1, cycles: 4},
+	0xBA: {mnemonic: "CP D", operation: operation{instruction: CP, target: D}, length: 1, cycles: 4},
+	0xBB: {mnemonic: "CP E", operation: operation{instruction: CP, target: E}, length: 1, cycles: 4},
+	0xBC: {mnemonic: "CP H", operation: operation{instruction: CP, target: H}, length: 1, cycles: 4},
+	0xBD: {mnemonic: "CP L", operation: operation{instruction: CP, target: L}, length: 1, cycles: 4},
+	0xBE: {mnemonic: "CP (HL)", operation: operation{instruction: CP, target: HLI}, length: 1, cycles: 8},
+	0xBF: {mnemonic: "CP A", operation: operation{instruction: CP, target: A}, length: 1, cycles: 4},
+	0xC0: {mnemonic: "RET NZ", operation: operation{instruction: RET, test: NotZero}, length: 1, cycles: 8, branch_cycles: 20},
+	0xC1: {mnemonic: "POP BC", operation: operation{instruction: POP, target: BC}, length: 1, cycles: 12},
+	0xC2: {mnemonic: "JP NZ,a16", operation: operation{instruction: JP, test: NotZero}, length: 3, cycles: 12, branch_cycles: 16},
+	0xC3: {mnemonic: "JP a16", operation: operation{instruction: JP, test: Always}, length: 3, cycles: 16},
+	0xC4: {mnemonic: "CALL NZ,a16", operation: operation{instruction: CALL, test: NotZero}, length: 3, cycles: 12, branch_cycles: 24},
+	0xC5: {mnemonic: "PUSH BC", operation: operation{instruction: PUSH, target: BC}, length: 1, cycles: 16},
+	0xC6: {mnemonic: "ADD A,d8", operation: operation{instruction: ADD, target: D8}, length: 2, cycles: 8},
+	0xC7: {mnemonic: "RST 00H", operation: operation{instruction: RST, vector: 0x00}, length: 1, cycles: 16},
+	0xC8: {mnemonic: "RET Z", operation: operation{instruction: RET, test: Zero}, length: 1, cycles: 8, branch_cycles: 20},
+	0xC9: {mnemonic: "RET", operation: operation{instruction: RET, test: Always}, length: 1, cycles: 16},
+	0xCA: {mnemonic: "JP Z,a16", operation: operation{instruction: JP, test: Zero}, length: 3, cycles: 12, branch_cycles: 16},
+	0xCB: {mnemonic: "PREFIX CB", operation: operation{instruction: PREFIX}, length: 1, cycles: 4},
+	0xCC: {mnemonic: "CALL Z,a16", operation: operation{instruction: CALL, test: Zero}, length: 3, cycles: 12, branch_cycles: 24},
+	0xCD: {mnemonic: "CALL a16", operation: operation{instruction: CALL, test: Always}, length: 3, cycles: 24},
+	0xCE: {mnemonic: "ADC A,d8", operation: operation{instruction: ADC, target: D8}, length: 2, cycles: 8},
+	0xCF: {mnemonic: "RST 08H", operation: operation{instruction: RST, vector: 0x08}, length: 1, cycles: 16},
+	0xD0: {mnemonic: "RET NC", operation: operation{instruction: RET, test: NotCarry}, length: 1, cycles: 8, branch_cycles: 20},
+	0xD1: {mnemonic: "POP DE", operation: operation{instruction: POP, target: DE}, length: 1, cycles: 12},
+	0xD2: {mnemonic: "JP NC,a16", operation: operation{instruction: JP, test: NotCarry}, length: 3, cycles: 12, branch_cycles: 16},
+	0xD3: {mnemonic: "ILLEGAL_D3", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xD4: {mnemonic: "CALL NC,a16", operation: operation{instruction: CALL, test: NotCarry}, length: 3, cycles: 12, branch_cycles: 24},
+	0xD5: {mnemonic: "PUSH DE", operation: operation{instruction: PUSH, target: DE}, length: 1, cycles: 16},
+	0xD6: {mnemonic: "SUB d8", operation: operation{instruction: SUB, target: D8}, length: 2, cycles: 8},
+	0xD7: {mnemonic: "RST 10H", operation: operation{instruction: RST, vector: 0x10}, length: 1, cycles: 16},
+	0xD8: {mnemonic: "RET C", operation: operation{instruction: RET, test: Carry}, length: 1, cycles: 8, branch_cycles: 20},
+	0xD9: {mnemonic: "RETI", operation: operation{instruction: RETI}, length: 1, cycles: 16},
+	0xDA: {mnemonic: "JP C,a16", operation: operation{instruction: JP, test: Carry}, length: 3, cycles: 12, branch_cycles: 16},
+	0xDB: {mnemonic: "ILLEGAL_DB", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xDC: {mnemonic: "CALL C,a16", operation: operation{instruction: CALL, test: Carry}, length: 3, cycles: 12, branch_cycles: 24},
+	0xDD: {mnemonic: "ILLEGAL_DD", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xDE: {mnemonic: "SBC A,d8", operation: operation{instruction: SBC, target: D8}, length: 2, cycles: 8},
+	0xDF: {mnemonic: "RST 18H", operation: operation{instruction: RST, vector: 0x18}, length: 1, cycles: 16},
+	0xE0: {mnemonic: "LDH (a8),A", operation: operation{instruction: LD, target: A8I, source: A}, length: 2, cycles: 12},
+	0xE1: {mnemonic: "POP HL", operation: operation{instruction: POP, target: HL}, length: 1, cycles: 12},
+	0xE2: {mnemonic: "LD (C),A", operation: operation{instruction: LD, target: CI, source: A}, length: 1, cycles: 8},
+	0xE3: {mnemonic: "ILLEGAL_E3", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xE4: {mnemonic: "ILLEGAL_E4", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xE5: {mnemonic: "PUSH HL", operation: operation{instruction: PUSH, target: HL}, length: 1, cycles: 16},
+	0xE6: {mnemonic: "AND d8", operation: operation{instruction: AND, target: D8}, length: 2, cycles: 8},
+	0xE7: {mnemonic: "RST 20H", operation: operation{instruction: RST, vector: 0x20}, length: 1, cycles: 16},
+	0xE8: {mnemonic: "ADD SP,r8", operation: operation{instruction: ADDSP}, length: 2, cycles: 16},
+	0xE9: {mnemonic: "JP (HL)", operation: operation{instruction: JPHL}, length: 1, cycles: 4},
+	0xEA: {mnemonic: "LD (a16),A", operation: operation{instruction: LD, target: A16I, source: A}, length: 3, cycles: 16},
+	0xEB: {mnemonic: "ILLEGAL_EB", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xEC: {mnemonic: "ILLEGAL_EC", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xED: {mnemonic: "ILLEGAL_ED", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xEE: {mnemonic: "XOR d8", operation: operation{instruction: XOR, target: D8}, length: 2, cycles: 8},
+	0xEF: {mnemonic: "RST 28H", operation: operation{instruction: RST, vector: 0x28}, length: 1, cycles: 16},
+	0xF0: {mnemonic: "LDH A,(a8)", operation: operation{instruction: LD, target: A, source: A8I}, length: 2, cycles: 12},
+	0xF1: {mnemonic: "POP AF", operation: operation{instruction: POP, target: AF}, length: 1, cycles: 12},
+	0xF2: {mnemonic: "LD A,(C)", operation: operation{instruction: LD, target: A, source: CI}, length: 1, cycles: 8},
+	0xF3: {mnemonic: "DI", operation: operation{instruction: DI}, length: 1, cycles: 4},
+	0xF4: {mnemonic: "ILLEGAL_F4", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xF5: {mnemonic: "PUSH AF", operation: operation{instruction: PUSH, target: AF}, length: 1, cycles: 16},
+	0xF6: {mnemonic: "OR d8", operation: operation{instruction: OR, target: D8}, length: 2, cycles: 8},
+	0xF7: {mnemonic: "RST 30H", operation: operation{instruction: RST, vector: 0x30}, length: 1, cycles: 16},
+	0xF8: {mnemonic: "LD HL,SP+r8", operation: operation{instruction: LDHL}, length: 2, cycles: 12},
+	0xF9: {mnemonic: "LD SP,HL", operation: operation{instruction: LD16, target: SP, source: HL}, length: 1, cycles: 8},
+	0xFA: {mnemonic: "LD A,(a16)", operation: operation{instruction: LD, target: A, source: A16I}, length: 3, cycles: 16},
+	0xFB: {mnemonic: "EI", operation: operation{instruction: EI}, length: 1, cycles: 4},
+	0xFC: {mnemonic: "ILLEGAL_FC", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xFD: {mnemonic: "ILLEGAL_FD", operation: operation{instruction: ILLEGAL}, length: 1, cycles: 4},
+	0xFE: {mnemonic: "CP d8", operation: operation{instruction: CP, target: D8}, length: 2, cycles: 8},
+	0xFF: {mnemonic: "RST 38H", operation: operation{instruction: RST, vector: 0x38}, length: 1, cycles: 16},
+}
+
+// the 0xCB prefixed opcodes are laid out in a grid, the top two bits pick
+// the group, the middle three the operation (or the bit for BIT, RES and
+// SET) and the lowest three the operand
+// https://gbdev.io/gb-opcodes/optables/
+var cbOpcodes = buildCBOpcodes()
+
+func buildCBOpcodes() [256]opcode {
+	targets := [8]ArithmeticTarget{B, C, D, E, H, L, HLI, A}
+	names := [8]string{"B", "C", "D", "E", "H", "L", "(HL)", "A"}
+	shifts := [8]struct {
+		name        string
+		instruction Instruction
+	}{
+		{"RLC", RLC},
+		{"RRC", RRC},
+		{"RL", RL},
+		{"RR", RR},
+		{"SLA", SLA},
+		{"SRA", SRA},
+		{"SWAP", SWAP},
+		{"SRL", SRL},
+	}
+
+	var table [256]opcode
+	for code := range 256 {
+		group, y, z := code>>6, (code>>3)&0b111, code&0b111
+		op := opcode{operation: operation{target: targets[z]}, length: 2, cycles: 8}
+		switch group {
+		case 0:
+			{
+				op.mnemonic = fmt.Sprintf("%s %s", shifts[y].name, names[z])
+				op.instruction = shifts[y].instruction
+			}
+		case 1:
+			{
+				op.mnemonic = fmt.Sprintf("BIT %d,%s", y, names[z])
+				op.instruction = BIT
+			}
+		case 2:
+			{
+				op.mnemonic = fmt.Sprintf("RES %d,%s", y, names[z])
+				op.instruction = RESET
+			}
+		case 3:
+			{
+				op.mnemonic = fmt.Sprintf("SET %d,%s", y, names[z])
+				op.instruction = SET
+			}
+		}
+		if group != 0 {
+			op.bit = uint8(y)
+		}
+		// (hl) has to be read from memory and written back, BIT only
+		// reads it
+		if targets[z] == HLI {
+			if op.instruction == BIT {
+				op.cycles = 12
+			} else {
+				op.cycles = 16
+			}
+		}
+		table[code] = op
+	}
+	return table
+}
+
+// the tables are written by hand so they get checked once when the program
+// starts instead of every time an instruction runs
+func init() {
+	for code, op := range opcodes {
+		if op.instruction == PREFIX || op.instruction == ILLEGAL {
+			continue
+		}
+		if err := op.validate(); err != nil {
+			panic(fmt.Sprintf("opcode 0x%02X %s: %v", code, op.mnemonic, err))
+		}
+	}
+	for code, op := range cbOpcodes {
+		if err := op.validate(); err != nil {
+			panic(fmt.Sprintf("opcode 0xCB 0x%02X %s: %v", code, op.mnemonic, err))
+		}
+	}
+}
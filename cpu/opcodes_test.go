@@ -0,0 +1,346 @@
+package cpu
+
+import "testing"
+
+// flat 64kb of memory so the tests can run programs without a real bus
+type testBus [0x10000]uint8
+
+func (b *testBus) Read(address uint16) uint8 {
+	return b[address]
+}
+
+func (b *testBus) Write(address uint16, value uint8) {
+	b[address] = value
+}
+
+// loads the program at 0x0000 and returns a cpu ready to run it
+func newTestCPU(program ...uint8) (*CPU, *testBus) {
+	bus := &testBus{}
+	copy(bus[:], program)
+	return New(bus), bus
+}
+
+func TestOpcodesTable(t *testing.T) {
+	illegal := map[uint8]bool{
+		0xD3: true, 0xDB: true, 0xDD: true, 0xE3: true, 0xE4: true, 0xEB: true,
+		0xEC: true, 0xED: true, 0xF4: true, 0xFC: true, 0xFD: true,
+	}
+	for code, op := range opcodes {
+		if illegal[uint8(code)] != (op.instruction == ILLEGAL) {
+			t.Errorf("failed : 0x%02X illegal expected : %t got : %s", code, illegal[uint8(code)], op.mnemonic)
+			continue
+		}
+		if op.mnemonic == "" {
+			t.Errorf("failed : 0x%02X is not decoded", code)
+			continue
+		}
+		if op.length < 1 || op.length > 3 {
+			t.Errorf("failed : %s has length %d", op.mnemonic, op.length)
+		}
+		if op.cycles == 0 || op.cycles%4 != 0 {
+			t.Errorf("failed : %s has %d cycles", op.mnemonic, op.cycles)
+		}
+	}
+}
+
+func TestStep(t *testing.T) {
+	type test struct {
+		title    string
+		program  []uint8
+		regs     Registers
+		steps    int
+		expected Registers
+		cycles   uint8
+	}
+
+	tests := []test{
+		{
+			title:    "nop",
+			program:  []uint8{0x00},
+			steps:    1,
+			expected: Registers{pc: 1},
+			cycles:   4,
+		},
+		{
+			title:    "add a,b",
+			program:  []uint8{0x80},
+			regs:     Registers{a: 2, b: 3},
+			steps:    1,
+			expected: Registers{a: 5, b: 3, pc: 1},
+			cycles:   4,
+		},
+		{
+			title:    "add a,d8",
+			program:  []uint8{0xC6, 0x10},
+			regs:     Registers{a: 2},
+			steps:    1,
+			expected: Registers{a: 0x12, pc: 2},
+			cycles:   8,
+		},
+		{
+			title:   "add a,(hl)",
+			program: []uint8{0x86, 0x00, 0x00, 0x07},
+			regs:    Registers{a: 2, l: 3},
+			steps:   1,
+			expected: Registers{
+				a:  9,
+				l:  3,
+				pc: 1,
+			},
+			cycles: 8,
+		},
+		{
+			title:    "inc b then dec c",
+			program:  []uint8{0x04, 0x0D},
+			regs:     Registers{b: 1, c: 2},
+			steps:    2,
+			expected: Registers{b: 2, c: 1, pc: 2, f: FlagsRegister{substract: true}},
+			cycles:   4,
+		},
+		{
+			title:    "cpl",
+			program:  []uint8{0x2F},
+			regs:     Registers{a: 0b10100101},
+			steps:    1,
+			expected: Registers{a: 0b01011010, pc: 1, f: FlagsRegister{substract: true, half_carry: true}},
+			cycles:   4,
+		},
+	}
+
+	for _, unit_test := range tests {
+		cpu, _ := newTestCPU(unit_test.program...)
+		cpu.regs = unit_test.regs
+		var cycles uint8
+		for range unit_test.steps {
+			cycles = cpu.Step()
+		}
+		success := true
+		if cpu.regs != unit_test.expected {
+			t.Errorf("failed : %s expected : %v got : %v", unit_test.title, &unit_test.expected, &cpu.regs)
+			success = false
+		}
+		if cycles != unit_test.cycles {
+			t.Errorf("failed : %s expected : %d cycles got : %d", unit_test.title, unit_test.cycles, cycles)
+			success = false
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
+
+func TestStepIncHLWritesBack(t *testing.T) {
+	cpu, bus := newTestCPU(0x34)
+	cpu.regs.h = 0xC0
+	bus[0xC000] = 0x0F
+	cpu.Step()
+	if bus[0xC000] != 0x10 {
+		t.Errorf("failed : inc (hl) expected : %d got : %d", 0x10, bus[0xC000])
+	}
+}
+
+func TestStepCB(t *testing.T) {
+	type test struct {
+		title    string
+		program  []uint8
+		regs     Registers
+		memory   uint8
+		expected Registers
+		result   uint8
+		cycles   uint8
+	}
+
+	tests := []test{
+		{
+			title:    "swap a",
+			program:  []uint8{0xCB, 0x37},
+			regs:     Registers{a: 0xA5},
+			expected: Registers{a: 0x5A, pc: 2},
+			cycles:   8,
+		},
+		{
+			title:    "swap b zero",
+			program:  []uint8{0xCB, 0x30},
+			expected: Registers{pc: 2, f: FlagsRegister{zero: true}},
+			cycles:   8,
+		},
+		{
+			title:    "rlc c",
+			program:  []uint8{0xCB, 0x01},
+			regs:     Registers{c: 0b11010100},
+			expected: Registers{c: 0b10101001, pc: 2, f: FlagsRegister{carry: true}},
+			cycles:   8,
+		},
+		{
+			title:    "bit 7,(hl)",
+			program:  []uint8{0xCB, 0x7E},
+			regs:     Registers{h: 0xC0},
+			memory:   0x80,
+			expected: Registers{h: 0xC0, pc: 2, f: FlagsRegister{half_carry: true}},
+			result:   0x80,
+			cycles:   12,
+		},
+		{
+			title:    "set 3,(hl)",
+			program:  []uint8{0xCB, 0xDE},
+			regs:     Registers{h: 0xC0},
+			expected: Registers{h: 0xC0, pc: 2},
+			result:   0b00001000,
+			cycles:   16,
+		},
+		{
+			title:    "res 0,(hl)",
+			program:  []uint8{0xCB, 0x86},
+			regs:     Registers{h: 0xC0},
+			memory:   0xFF,
+			expected: Registers{h: 0xC0, pc: 2},
+			result:   0xFE,
+			cycles:   16,
+		},
+		{
+			title:    "swap (hl)",
+			program:  []uint8{0xCB, 0x36},
+			regs:     Registers{h: 0xC0},
+			memory:   0xF1,
+			expected: Registers{h: 0xC0, pc: 2},
+			result:   0x1F,
+			cycles:   16,
+		},
+		{
+			title:    "set 7,a",
+			program:  []uint8{0xCB, 0xFF},
+			expected: Registers{a: 0x80, pc: 2},
+			cycles:   8,
+		},
+	}
+
+	for _, unit_test := range tests {
+		cpu, bus := newTestCPU(unit_test.program...)
+		cpu.regs = unit_test.regs
+		bus[0xC000] = unit_test.memory
+		cycles := cpu.Step()
+		success := true
+		if cpu.regs != unit_test.expected {
+			t.Errorf("failed : %s expected : %v got : %v", unit_test.title, &unit_test.expected, &cpu.regs)
+			success = false
+		}
+		if bus[0xC000] != unit_test.result {
+			t.Errorf("failed : %s expected (hl) : %08b got : %08b", unit_test.title, unit_test.result, bus[0xC000])
+			success = false
+		}
+		if cycles != unit_test.cycles {
+			t.Errorf("failed : %s expected : %d cycles got : %d", unit_test.title, unit_test.cycles, cycles)
+			success = false
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
+
+func TestCBOpcodesTable(t *testing.T) {
+	expected := map[uint8]string{
+		0x00: "RLC B",
+		0x0E: "RRC (HL)",
+		0x17: "RL A",
+		0x18: "RR B",
+		0x26: "SLA (HL)",
+		0x2F: "SRA A",
+		0x33: "SWAP E",
+		0x3F: "SRL A",
+		0x40: "BIT 0,B",
+		0x7E: "BIT 7,(HL)",
+		0x95: "RES 2,L",
+		0xFF: "SET 7,A",
+	}
+	for code, mnemonic := range expected {
+		if cbOpcodes[code].mnemonic != mnemonic {
+			t.Errorf("failed : 0xCB 0x%02X expected : %s got : %s", code, mnemonic, cbOpcodes[code].mnemonic)
+		}
+	}
+}
+
+// runs an opcode with the flags set so its condition (if it has one) holds or
+// not and returns the cycles Step counted
+func stepOpcode(program []uint8, test JumpTest, taken bool) uint8 {
+	cpu, _ := newTestCPU(program...)
+	cpu.regs.sp = 0xD000
+	switch test {
+	case NotZero:
+		{
+			cpu.regs.f.zero = !taken
+		}
+	case Zero:
+		{
+			cpu.regs.f.zero = taken
+		}
+	case NotCarry:
+		{
+			cpu.regs.f.carry = !taken
+		}
+	case Carry:
+		{
+			cpu.regs.f.carry = taken
+		}
+	}
+	return cpu.Step()
+}
+
+func TestOpcodesCycles(t *testing.T) {
+	for code, op := range opcodes {
+		// HALT and STOP idle, they get tested on their own
+		if op.mnemonic == "" || op.instruction == PREFIX || op.instruction == HALT || op.instruction == STOP {
+			continue
+		}
+		program := []uint8{uint8(code), 0x00, 0x00}
+		test, conditional := Always, op.branch_cycles != 0
+		if conditional {
+			test = op.test
+			if cycles := stepOpcode(program, test, true); cycles != op.branch_cycles {
+				t.Errorf("failed : %s taken expected : %d cycles got : %d", op.mnemonic, op.branch_cycles, cycles)
+			}
+		}
+		if cycles := stepOpcode(program, test, false); cycles != op.cycles {
+			t.Errorf("failed : %s expected : %d cycles got : %d", op.mnemonic, op.cycles, cycles)
+		}
+	}
+	for code, op := range cbOpcodes {
+		if cycles := stepOpcode([]uint8{0xCB, uint8(code)}, Always, false); cycles != op.cycles {
+			t.Errorf("failed : %s expected : %d cycles got : %d", op.mnemonic, op.cycles, cycles)
+		}
+	}
+}
+
+type cycleCounter struct {
+	cycles int
+	calls  int
+}
+
+func (c *cycleCounter) Advance(cycles uint8) {
+	c.cycles += int(cycles)
+	c.calls++
+}
+
+func TestConnectedComponents(t *testing.T) {
+	// CALL 0x0010, at 0x0010 INC (HL)
+	cpu, bus := newTestCPU(0xCD, 0x10, 0x00)
+	bus[0x10] = 0x34
+	cpu.regs.sp = 0xD000
+	cpu.regs.h = 0xC0
+	counter := &cycleCounter{}
+	cpu.Connect(counter)
+
+	total := int(cpu.Step())
+	total += int(cpu.Step())
+
+	if total != 36 || cpu.Cycles() != 36 {
+		t.Errorf("failed : expected : 36 cycles got : %d (cpu %d)", total, cpu.Cycles())
+	}
+	if counter.cycles != total {
+		t.Errorf("failed : component expected : %d cycles got : %d", total, counter.cycles)
+	}
+	// advanced one m-cycle at a time
+	if counter.calls != 9 {
+		t.Errorf("failed : component expected : 9 calls got : %d", counter.calls)
+	}
+}
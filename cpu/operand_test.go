@@ -0,0 +1,82 @@
+package cpu
+
+import "testing"
+
+// every 8 bit handler has to give the same result and flags no matter if the
+// value comes from a register, from (hl) or from an immediate, the only
+// difference is the m-cycles spent reading and writing memory
+func TestOperandSources(t *testing.T) {
+	type test struct {
+		instruction Instruction
+		// reads the operand without storing anything back into it
+		read_only bool
+	}
+
+	tests := []test{
+		{instruction: ADD, read_only: true},
+		{instruction: ADC, read_only: true},
+		{instruction: SUB, read_only: true},
+		{instruction: SBC, read_only: true},
+		{instruction: AND, read_only: true},
+		{instruction: OR, read_only: true},
+		{instruction: XOR, read_only: true},
+		{instruction: CP, read_only: true},
+		{instruction: BIT, read_only: true},
+		{instruction: INC},
+		{instruction: DEC},
+		{instruction: RL},
+		{instruction: RLC},
+		{instruction: RR},
+		{instruction: RRC},
+		{instruction: SLA},
+		{instruction: SRA},
+		{instruction: SRL},
+		{instruction: SWAP},
+		{instruction: RESET},
+		{instruction: SET},
+	}
+
+	values := []reg{0x00, 0x01, 0x0F, 0x80, 0xA5, 0xFF}
+
+	for _, unit_test := range tests {
+		for _, value := range values {
+			for flags := range 16 {
+				from_register, _ := newTestCPU()
+				from_register.regs.a = 0x3C
+				from_register.regs.b = value
+				from_register.regs.f = toFlagReg(uint8(flags << 4))
+				register_cycles := from_register.execute(operation{instruction: unit_test.instruction, target: B, bit: 3})
+
+				from_memory, bus := newTestCPU()
+				from_memory.regs.a = 0x3C
+				from_memory.regs.h = 0xC0
+				from_memory.regs.f = toFlagReg(uint8(flags << 4))
+				bus[0xC000] = uint8(value)
+				memory_cycles := from_memory.execute(operation{instruction: unit_test.instruction, target: HLI, bit: 3})
+
+				from_immediate, _ := newTestCPU()
+				from_immediate.regs.a = 0x3C
+				from_immediate.regs.f = toFlagReg(uint8(flags << 4))
+				immediate_cycles := from_immediate.execute(operation{instruction: unit_test.instruction, target: D8, bit: 3, immediate: uint16(value)})
+
+				if from_memory.regs.a != from_register.regs.a || from_immediate.regs.a != from_register.regs.a {
+					t.Errorf("failed : %d 0x%02X expected a : 0x%02X got : 0x%02X (hl) 0x%02X (d8)", unit_test.instruction, value, from_register.regs.a, from_memory.regs.a, from_immediate.regs.a)
+				}
+				if from_memory.regs.f != from_register.regs.f || from_immediate.regs.f != from_register.regs.f {
+					t.Errorf("failed : %d 0x%02X expected f : %v got : %v (hl) %v (d8)", unit_test.instruction, value, from_register.regs.f, from_memory.regs.f, from_immediate.regs.f)
+				}
+				if reg(bus[0xC000]) != from_register.regs.b {
+					t.Errorf("failed : %d 0x%02X expected (hl) : 0x%02X got : 0x%02X", unit_test.instruction, value, from_register.regs.b, bus[0xC000])
+				}
+
+				expected_memory_cycles := uint8(8)
+				if unit_test.read_only {
+					expected_memory_cycles = 4
+				}
+				if register_cycles != 0 || immediate_cycles != 0 || memory_cycles != expected_memory_cycles {
+					t.Errorf("failed : %d expected cycles : 0 %d 0 got : %d %d %d", unit_test.instruction, expected_memory_cycles, register_cycles, memory_cycles, immediate_cycles)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package cpu
+
+import "fmt"
+
+// a decoded instruction, everything execute needs to run it, which fields
+// matter depends on the instruction:
+//
+//	target    the register or memory location that gets modified (or read
+//	          for the ALU ops that only work on A)
+//	source    where LD and LD16 read from
+//	test      the condition of JP, JR, CALL and RET
+//	bit       the bit BIT, RES and SET work on
+//	vector    the address RST jumps to
+//	immediate the d8/d16/a8/a16/r8 that came after the opcode
+type operation struct {
+	instruction Instruction
+	target      ArithmeticTarget
+	source      ArithmeticTarget
+	test        JumpTest
+	bit         uint8
+	vector      uint8
+	immediate   uint16
+}
+
+func isRegister(target ArithmeticTarget) bool {
+	return target <= L
+}
+
+func isMemory(target ArithmeticTarget) bool {
+	switch target {
+	case HLI, HLIP, HLIM, BCI, DEI, A16I, A8I, CI:
+		{
+			return true
+		}
+	}
+	return false
+}
+
+// the 8 bit handlers (alu, inc/dec, rotates, shifts and bit ops) work on a
+// register, the byte at (hl) or an immediate, the ones that modify an
+// immediate only leave the flags behind
+func isOperand(target ArithmeticTarget) bool {
+	return isRegister(target) || target == HLI || target == D8
+}
+
+// validate reports the combinations the handlers cant run (SWAP (BC), BIT 9,
+// PUSH SP, LD (BC),(HL)...) so execute never has to guess
+func (op operation) validate() error {
+	switch op.instruction {
+	case ADD, ADC, SUB, SBC, AND, OR, XOR, CP, INC, DEC, RL, RLC, RR, RRC, SLL, SLA, SRA, SRL, SWAP:
+		{
+			if !isOperand(op.target) {
+				return fmt.Errorf("instruction %d cant use target %d", op.instruction, op.target)
+			}
+		}
+	case BIT, RESET, SET:
+		{
+			if !isOperand(op.target) {
+				return fmt.Errorf("instruction %d cant use target %d", op.instruction, op.target)
+			}
+			if op.bit > 7 {
+				return fmt.Errorf("instruction %d cant use bit %d", op.instruction, op.bit)
+			}
+		}
+	case LD:
+		{
+			if !isRegister(op.target) && !isMemory(op.target) {
+				return fmt.Errorf("LD cant store into target %d", op.target)
+			}
+			if !isRegister(op.source) && !isMemory(op.source) && op.source != D8 {
+				return fmt.Errorf("LD cant read from source %d", op.source)
+			}
+			if isMemory(op.target) && (isMemory(op.source) || op.source == D8 && op.target != HLI) {
+				return fmt.Errorf("LD cant copy from %d to %d", op.source, op.target)
+			}
+			// only (hl) can be used with any register, the rest of the
+			// memory targets only work with A
+			if op.target != HLI && isMemory(op.target) && op.source != A ||
+				op.source != HLI && isMemory(op.source) && op.target != A {
+				return fmt.Errorf("LD cant copy from %d to %d", op.source, op.target)
+			}
+		}
+	case LD16:
+		{
+			switch {
+			case op.source == D16 && (op.target == BC || op.target == DE || op.target == HL || op.target == SP):
+			case op.source == HL && op.target == SP:
+			case op.source == SP && op.target == A16I:
+			default:
+				{
+					return fmt.Errorf("LD16 cant copy from %d to %d", op.source, op.target)
+				}
+			}
+		}
+	case INC16, DEC16, ADDHL:
+		{
+			if op.target < BC || op.target > SP {
+				return fmt.Errorf("instruction %d cant use target %d", op.instruction, op.target)
+			}
+		}
+	case PUSH, POP:
+		{
+			if op.target != BC && op.target != DE && op.target != HL && op.target != AF {
+				return fmt.Errorf("instruction %d cant use target %d", op.instruction, op.target)
+			}
+		}
+	case JP, JR, CALL, RET:
+		{
+			if op.test > Carry {
+				return fmt.Errorf("instruction %d cant use condition %d", op.instruction, op.test)
+			}
+		}
+	case RST:
+		{
+			if op.vector%8 != 0 || op.vector > 0x38 {
+				return fmt.Errorf("RST cant jump to 0x%02X", op.vector)
+			}
+		}
+	case NOP, DAA, CPL, CCF, SCF, RLA, RLCA, RRA, RRCA, LDHL, ADDSP, JPHL, RETI, DI, EI, HALT, STOP:
+		{
+		}
+	default:
+		{
+			// PREFIX and ILLEGAL are handled by the decoder and never reach
+			// execute
+			return fmt.Errorf("instruction %d cant be executed", op.instruction)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package cpu
+
+import "testing"
+
+func TestOperationValidate(t *testing.T) {
+	type test struct {
+		title string
+		op    operation
+		valid bool
+	}
+
+	tests := []test{
+		{title: "add a,c", op: operation{instruction: ADD, target: C}, valid: true},
+		{title: "add a,d8", op: operation{instruction: ADD, target: D8}, valid: true},
+		{title: "add a,(bc)", op: operation{instruction: ADD, target: BCI}},
+		{title: "add a,bc", op: operation{instruction: ADD, target: BC}},
+		{title: "inc (hl)", op: operation{instruction: INC, target: HLI}, valid: true},
+		{title: "inc d8", op: operation{instruction: INC, target: D8}, valid: true},
+		{title: "swap d8", op: operation{instruction: SWAP, target: D8}, valid: true},
+		{title: "swap (bc)", op: operation{instruction: SWAP, target: BCI}},
+		{title: "rl (hl+)", op: operation{instruction: RL, target: HLIP}},
+		{title: "bit 7,(hl)", op: operation{instruction: BIT, target: HLI, bit: 7}, valid: true},
+		{title: "bit 8,a", op: operation{instruction: BIT, target: A, bit: 8}},
+		{title: "set 9,b", op: operation{instruction: SET, target: B, bit: 9}},
+		{title: "res 0,d8", op: operation{instruction: RESET, target: D8}, valid: true},
+		{title: "bit 3,d8", op: operation{instruction: BIT, target: D8, bit: 3}, valid: true},
+		{title: "set 1,(de)", op: operation{instruction: SET, target: DEI, bit: 1}},
+		{title: "ld b,c", op: operation{instruction: LD, target: B, source: C}, valid: true},
+		{title: "ld (hl),d8", op: operation{instruction: LD, target: HLI, source: D8}, valid: true},
+		{title: "ld (bc),a", op: operation{instruction: LD, target: BCI, source: A}, valid: true},
+		{title: "ld a,(c)", op: operation{instruction: LD, target: A, source: CI}, valid: true},
+		{title: "ld (bc),b", op: operation{instruction: LD, target: BCI, source: B}},
+		{title: "ld (bc),d8", op: operation{instruction: LD, target: BCI, source: D8}},
+		{title: "ld (hl),(hl)", op: operation{instruction: LD, target: HLI, source: HLI}},
+		{title: "ld d8,a", op: operation{instruction: LD, target: D8, source: A}},
+		{title: "ld b,(a16)", op: operation{instruction: LD, target: B, source: A16I}},
+		{title: "ld bc,d16", op: operation{instruction: LD16, target: BC, source: D16}, valid: true},
+		{title: "ld sp,hl", op: operation{instruction: LD16, target: SP, source: HL}, valid: true},
+		{title: "ld (a16),sp", op: operation{instruction: LD16, target: A16I, source: SP}, valid: true},
+		{title: "ld af,d16", op: operation{instruction: LD16, target: AF, source: D16}},
+		{title: "ld bc,de", op: operation{instruction: LD16, target: BC, source: DE}},
+		{title: "inc sp", op: operation{instruction: INC16, target: SP}, valid: true},
+		{title: "inc af", op: operation{instruction: INC16, target: AF}},
+		{title: "add hl,a", op: operation{instruction: ADDHL, target: A}},
+		{title: "push af", op: operation{instruction: PUSH, target: AF}, valid: true},
+		{title: "push sp", op: operation{instruction: PUSH, target: SP}},
+		{title: "pop d8", op: operation{instruction: POP, target: D8}},
+		{title: "jp c", op: operation{instruction: JP, test: Carry}, valid: true},
+		{title: "jr bad condition", op: operation{instruction: JR, test: Carry + 1}},
+		{title: "rst 38h", op: operation{instruction: RST, vector: 0x38}, valid: true},
+		{title: "rst 39h", op: operation{instruction: RST, vector: 0x39}},
+		{title: "rst 40h", op: operation{instruction: RST, vector: 0x40}},
+		{title: "prefix", op: operation{instruction: PREFIX}},
+		{title: "unknown instruction", op: operation{instruction: PREFIX + 1}},
+	}
+
+	for _, unit_test := range tests {
+		err := unit_test.op.validate()
+		if unit_test.valid && err != nil {
+			t.Errorf("failed : %s expected to be valid got : %v", unit_test.title, err)
+		} else if !unit_test.valid && err == nil {
+			t.Errorf("failed : %s expected an error", unit_test.title)
+		} else {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
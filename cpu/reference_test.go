@@ -0,0 +1,366 @@
+package cpu
+
+// a second implementation of the 8 bit alu ported from references/opcodes.cpp,
+// it only knows about a and f as plain bytes so it doesnt share any code with
+// the real handlers and can be used to check them
+type referenceCPU struct {
+	a uint8
+	f uint8
+}
+
+func (r *referenceCPU) setFlag(flag uint8, value bool) {
+	if value {
+		r.f |= flag
+	} else {
+		r.f &^= flag
+	}
+}
+
+func (r *referenceCPU) flagCarryValue() uint8 {
+	return (r.f & CARRY_FLAG) >> CARRY_FLAG_BYTE_POSITION
+}
+
+func checkBit(value, bit uint8) bool {
+	return (value>>bit)&1 == 1
+}
+
+func (r *referenceCPU) adc(value uint8) {
+	reg := r.a
+	carry := r.flagCarryValue()
+	result_full := uint(reg) + uint(value) + uint(carry)
+	result := uint8(result_full)
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(SUBTRACT_FLAG, false)
+	r.setFlag(HALF_CARRY_FLAG, (reg&0xF)+(value&0xF)+carry > 0xF)
+	r.setFlag(CARRY_FLAG, result_full > 0xFF)
+	r.a = result
+}
+
+func (r *referenceCPU) add(value uint8) {
+	reg := r.a
+	result := uint(reg) + uint(value)
+	r.a = uint8(result)
+	r.setFlag(ZERO_FLAG, r.a == 0)
+	r.setFlag(SUBTRACT_FLAG, false)
+	r.setFlag(HALF_CARRY_FLAG, (reg&0xF)+(value&0xF) > 0xF)
+	r.setFlag(CARRY_FLAG, result&0x100 != 0)
+}
+
+func (r *referenceCPU) and(value uint8) {
+	r.a &= value
+	r.setFlag(ZERO_FLAG, r.a == 0)
+	r.setFlag(HALF_CARRY_FLAG, true)
+	r.setFlag(CARRY_FLAG, false)
+	r.setFlag(SUBTRACT_FLAG, false)
+}
+
+func (r *referenceCPU) bit(bit, value uint8) {
+	r.setFlag(ZERO_FLAG, !checkBit(value, bit))
+	r.setFlag(SUBTRACT_FLAG, false)
+	r.setFlag(HALF_CARRY_FLAG, true)
+}
+
+func (r *referenceCPU) ccf() {
+	r.setFlag(SUBTRACT_FLAG, false)
+	r.setFlag(HALF_CARRY_FLAG, false)
+	r.setFlag(CARRY_FLAG, r.f&CARRY_FLAG == 0)
+}
+
+func (r *referenceCPU) cp(value uint8) {
+	reg := r.a
+	result := reg - value
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(SUBTRACT_FLAG, true)
+	r.setFlag(HALF_CARRY_FLAG, int(reg&0xF)-int(value&0xF) < 0)
+	r.setFlag(CARRY_FLAG, reg < value)
+}
+
+func (r *referenceCPU) cpl() {
+	r.a = ^r.a
+	r.setFlag(SUBTRACT_FLAG, true)
+	r.setFlag(HALF_CARRY_FLAG, true)
+}
+
+func (r *referenceCPU) dec(value uint8) uint8 {
+	value--
+	r.setFlag(ZERO_FLAG, value == 0)
+	r.setFlag(SUBTRACT_FLAG, true)
+	r.setFlag(HALF_CARRY_FLAG, value&0x0F == 0x0F)
+	return value
+}
+
+func (r *referenceCPU) inc(value uint8) uint8 {
+	value++
+	r.setFlag(ZERO_FLAG, value == 0)
+	r.setFlag(SUBTRACT_FLAG, false)
+	r.setFlag(HALF_CARRY_FLAG, value&0x0F == 0x00)
+	return value
+}
+
+func (r *referenceCPU) or(value uint8) {
+	r.a |= value
+	r.setFlag(ZERO_FLAG, r.a == 0)
+	r.setFlag(HALF_CARRY_FLAG, false)
+	r.setFlag(CARRY_FLAG, false)
+	r.setFlag(SUBTRACT_FLAG, false)
+}
+
+func (r *referenceCPU) res(bit, value uint8) uint8 {
+	return value &^ (1 << bit)
+}
+
+func (r *referenceCPU) rl(value uint8) uint8 {
+	carry := r.flagCarryValue()
+	r.setFlag(CARRY_FLAG, checkBit(value, 7))
+	result := value<<1 | carry
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(SUBTRACT_FLAG, false)
+	r.setFlag(HALF_CARRY_FLAG, false)
+	return result
+}
+
+func (r *referenceCPU) rla() {
+	r.a = r.rl(r.a)
+	r.setFlag(ZERO_FLAG, false)
+}
+
+func (r *referenceCPU) rlc(value uint8) uint8 {
+	truncated_bit := value >> 7
+	result := value<<1 | truncated_bit
+	r.setFlag(CARRY_FLAG, truncated_bit == 1)
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(HALF_CARRY_FLAG, false)
+	r.setFlag(SUBTRACT_FLAG, false)
+	return result
+}
+
+func (r *referenceCPU) rlca() {
+	r.a = r.rlc(r.a)
+	r.setFlag(ZERO_FLAG, false)
+}
+
+func (r *referenceCPU) rr(value uint8) uint8 {
+	carry := r.flagCarryValue()
+	r.setFlag(CARRY_FLAG, checkBit(value, 0))
+	result := value>>1 | carry<<7
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(SUBTRACT_FLAG, false)
+	r.setFlag(HALF_CARRY_FLAG, false)
+	return result
+}
+
+func (r *referenceCPU) rra() {
+	r.a = r.rr(r.a)
+	r.setFlag(ZERO_FLAG, false)
+}
+
+func (r *referenceCPU) rrc(value uint8) uint8 {
+	truncated_bit := value & 1
+	result := value>>1 | truncated_bit<<7
+	r.setFlag(CARRY_FLAG, truncated_bit == 1)
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(HALF_CARRY_FLAG, false)
+	r.setFlag(SUBTRACT_FLAG, false)
+	return result
+}
+
+func (r *referenceCPU) rrca() {
+	r.a = r.rrc(r.a)
+	r.setFlag(ZERO_FLAG, false)
+}
+
+func (r *referenceCPU) sbc(value uint8) {
+	carry := r.flagCarryValue()
+	reg := r.a
+	result_full := int(reg) - int(value) - int(carry)
+	result := uint8(result_full)
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(SUBTRACT_FLAG, true)
+	r.setFlag(CARRY_FLAG, result_full < 0)
+	r.setFlag(HALF_CARRY_FLAG, int(reg&0xF)-int(value&0xF)-int(carry) < 0)
+	r.a = result
+}
+
+func (r *referenceCPU) scf() {
+	r.setFlag(CARRY_FLAG, true)
+	r.setFlag(HALF_CARRY_FLAG, false)
+	r.setFlag(SUBTRACT_FLAG, false)
+}
+
+func (r *referenceCPU) set(bit, value uint8) uint8 {
+	return value | 1<<bit
+}
+
+func (r *referenceCPU) sla(value uint8) uint8 {
+	result := value << 1
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(CARRY_FLAG, checkBit(value, 7))
+	r.setFlag(HALF_CARRY_FLAG, false)
+	r.setFlag(SUBTRACT_FLAG, false)
+	return result
+}
+
+func (r *referenceCPU) sra(value uint8) uint8 {
+	result := value>>1 | value&0x80
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(CARRY_FLAG, checkBit(value, 0))
+	r.setFlag(HALF_CARRY_FLAG, false)
+	r.setFlag(SUBTRACT_FLAG, false)
+	return result
+}
+
+func (r *referenceCPU) srl(value uint8) uint8 {
+	result := value >> 1
+	r.setFlag(CARRY_FLAG, checkBit(value, 0))
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(HALF_CARRY_FLAG, false)
+	r.setFlag(SUBTRACT_FLAG, false)
+	return result
+}
+
+func (r *referenceCPU) sub(value uint8) {
+	reg := r.a
+	r.a = reg - value
+	r.setFlag(ZERO_FLAG, r.a == 0)
+	r.setFlag(SUBTRACT_FLAG, true)
+	r.setFlag(HALF_CARRY_FLAG, int(reg&0xF)-int(value&0xF) < 0)
+	r.setFlag(CARRY_FLAG, reg < value)
+}
+
+func (r *referenceCPU) swap(value uint8) uint8 {
+	result := value<<4 | value>>4
+	r.setFlag(ZERO_FLAG, result == 0)
+	r.setFlag(SUBTRACT_FLAG, false)
+	r.setFlag(HALF_CARRY_FLAG, false)
+	r.setFlag(CARRY_FLAG, false)
+	return result
+}
+
+func (r *referenceCPU) xor(value uint8) {
+	r.a ^= value
+	r.setFlag(ZERO_FLAG, r.a == 0)
+	r.setFlag(SUBTRACT_FLAG, false)
+	r.setFlag(HALF_CARRY_FLAG, false)
+	r.setFlag(CARRY_FLAG, false)
+}
+
+// runs the operation on the reference, value is the operand and the result
+// of the instructions that modify it comes back, the ones that only work on
+// a (or dont have an operand) return value untouched
+func (r *referenceCPU) run(op operation, value uint8) uint8 {
+	switch op.instruction {
+	case ADD:
+		{
+			r.add(value)
+		}
+	case ADC:
+		{
+			r.adc(value)
+		}
+	case SUB:
+		{
+			r.sub(value)
+		}
+	case SBC:
+		{
+			r.sbc(value)
+		}
+	case AND:
+		{
+			r.and(value)
+		}
+	case OR:
+		{
+			r.or(value)
+		}
+	case XOR:
+		{
+			r.xor(value)
+		}
+	case CP:
+		{
+			r.cp(value)
+		}
+	case INC:
+		{
+			value = r.inc(value)
+		}
+	case DEC:
+		{
+			value = r.dec(value)
+		}
+	case RLA:
+		{
+			r.rla()
+		}
+	case RLCA:
+		{
+			r.rlca()
+		}
+	case RRA:
+		{
+			r.rra()
+		}
+	case RRCA:
+		{
+			r.rrca()
+		}
+	case CPL:
+		{
+			r.cpl()
+		}
+	case CCF:
+		{
+			r.ccf()
+		}
+	case SCF:
+		{
+			r.scf()
+		}
+	case RL:
+		{
+			value = r.rl(value)
+		}
+	case RLC:
+		{
+			value = r.rlc(value)
+		}
+	case RR:
+		{
+			value = r.rr(value)
+		}
+	case RRC:
+		{
+			value = r.rrc(value)
+		}
+	case SLA:
+		{
+			value = r.sla(value)
+		}
+	case SRA:
+		{
+			value = r.sra(value)
+		}
+	case SRL:
+		{
+			value = r.srl(value)
+		}
+	case SWAP:
+		{
+			value = r.swap(value)
+		}
+	case BIT:
+		{
+			r.bit(op.bit, value)
+		}
+	case RESET:
+		{
+			value = r.res(op.bit, value)
+		}
+	case SET:
+		{
+			value = r.set(op.bit, value)
+		}
+	}
+	return value
+}
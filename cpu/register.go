@@ -19,9 +19,13 @@ type Registers struct {
 	f FlagsRegister
 	h reg
 	l reg
+	// program counter, points to the next byte to fetch
+	pc uint16
+	// stack pointer, the stack grows downwards
+	sp uint16
 }
 
-// i think this doesnt check correctly for overflow stuff
+// wraps around like the hardware does, the bool is the carry out of bit 7
 func (r *reg) overflowingAdd(b reg) (reg, bool) {
 	var overflow bool
 	result := (*r) + b
@@ -31,7 +35,7 @@ func (r *reg) overflowingAdd(b reg) (reg, bool) {
 	return result, overflow
 }
 
-// i think this doesnt check correctly for overflow stuff
+// wraps around like the hardware does, the bool is the borrow into bit 7
 func (r *reg) overflowingSub(b reg) (reg, bool) {
 	var overflow bool
 	result := (*r) - b
@@ -52,8 +56,10 @@ func (r *Registers) String() string {
 			f: %v
 			h: %d
 			l: %d
+			pc: 0x%04X
+			sp: 0x%04X
 		]
-		`, r.a, r.b, r.c, r.d, r.e, r.f, r.h, r.l)
+		`, r.a, r.b, r.c, r.d, r.e, r.f, r.h, r.l, r.pc, r.sp)
 }
 
 func (r *Registers) getBC() uint16 {
@@ -78,6 +84,36 @@ func (r *Registers) setBC(value uint16) {
 	r.c = c
 }
 
+func (r *Registers) getDE() uint16 {
+	return uint16(r.d)<<8 | uint16(r.e)
+}
+
+func (r *Registers) setDE(value uint16) {
+	r.d = reg((value & 0xFF00) >> 8)
+	r.e = reg(value & 0xFF)
+}
+
+func (r *Registers) getHL() uint16 {
+	return uint16(r.h)<<8 | uint16(r.l)
+}
+
+func (r *Registers) setHL(value uint16) {
+	r.h = reg((value & 0xFF00) >> 8)
+	r.l = reg(value & 0xFF)
+}
+
+// the lower nibble of f doesnt exist on the hardware, it always reads as
+// zero and anything written to it is lost, toBit and toFlagReg already only
+// know about the upper nibble so they take care of that
+func (r *Registers) getAF() uint16 {
+	return uint16(r.a)<<8 | uint16(r.f.toBit())
+}
+
+func (r *Registers) setAF(value uint16) {
+	r.a = reg((value & 0xFF00) >> 8)
+	r.f = toFlagReg(uint8(value & 0xFF))
+}
+
 type FlagsRegister struct {
 	zero       bool
 	substract  bool
@@ -0,0 +1,30 @@
+package cpu
+
+// the stack grows downwards, sp points to the last byte pushed and the high
+// byte is pushed first so the value ends up little endian in memory
+func (cpu *CPU) push(value uint16) {
+	cpu.regs.sp--
+	cpu.write(cpu.regs.sp, uint8(value>>8))
+	cpu.regs.sp--
+	cpu.write(cpu.regs.sp, uint8(value&0xFF))
+}
+
+func (cpu *CPU) pop() uint16 {
+	low := uint16(cpu.read(cpu.regs.sp))
+	cpu.regs.sp++
+	high := uint16(cpu.read(cpu.regs.sp))
+	cpu.regs.sp++
+	return high<<8 | low
+}
+
+// PUSH rr, it takes one m-cycle to decrement sp before the writes
+func (cpu *CPU) pushTarget(target ArithmeticTarget) {
+	cpu.tick()
+	cpu.push(cpu.getWideByTarget(target))
+}
+
+// POP rr, POP AF goes through setAF so the low nibble of f is thrown away
+// like the hardware does
+func (cpu *CPU) popTarget(target ArithmeticTarget) {
+	cpu.setWideByTarget(target, cpu.pop())
+}
@@ -0,0 +1,109 @@
+package cpu
+
+import "testing"
+
+func TestPushPop(t *testing.T) {
+	type test struct {
+		title    string
+		program  []uint8
+		regs     Registers
+		steps    int
+		expected Registers
+		written  map[uint16]uint8
+		// cycles of the last step
+		cycles uint8
+	}
+
+	tests := []test{
+		{
+			title:    "push bc",
+			program:  []uint8{0xC5},
+			regs:     Registers{b: 0x12, c: 0x34, sp: 0xFFFE},
+			expected: Registers{b: 0x12, c: 0x34, sp: 0xFFFC, pc: 1},
+			written:  map[uint16]uint8{0xFFFD: 0x12, 0xFFFC: 0x34},
+			cycles:   16,
+		},
+		{
+			title:   "push af",
+			program: []uint8{0xF5},
+			regs: Registers{
+				a:  0x80,
+				f:  FlagsRegister{zero: true, carry: true},
+				sp: 0xD000,
+			},
+			expected: Registers{
+				a:  0x80,
+				f:  FlagsRegister{zero: true, carry: true},
+				sp: 0xCFFE,
+				pc: 1,
+			},
+			written: map[uint16]uint8{0xCFFF: 0x80, 0xCFFE: 0x90},
+			cycles:  16,
+		},
+		{
+			title:    "push then pop de into hl",
+			program:  []uint8{0xD5, 0xE1},
+			regs:     Registers{d: 0xBE, e: 0xEF, sp: 0xFFFE},
+			steps:    2,
+			expected: Registers{d: 0xBE, e: 0xEF, h: 0xBE, l: 0xEF, sp: 0xFFFE, pc: 2},
+			cycles:   12,
+		},
+	}
+
+	for _, unit_test := range tests {
+		cpu, bus := newTestCPU(unit_test.program...)
+		cpu.regs = unit_test.regs
+		cycles := cpu.Step()
+		for step := 1; step < unit_test.steps; step++ {
+			cycles = cpu.Step()
+		}
+		success := true
+		if cpu.regs != unit_test.expected {
+			t.Errorf("failed : %s expected : %v got : %v", unit_test.title, &unit_test.expected, &cpu.regs)
+			success = false
+		}
+		for address, value := range unit_test.written {
+			if bus[address] != value {
+				t.Errorf("failed : %s expected (0x%04X) : 0x%02X got : 0x%02X", unit_test.title, address, value, bus[address])
+				success = false
+			}
+		}
+		if cycles != unit_test.cycles {
+			t.Errorf("failed : %s expected : %d cycles got : %d", unit_test.title, unit_test.cycles, cycles)
+			success = false
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
+
+// every value of f goes through the stack, the low nibble must never make it
+// back into f no matter how it got to the stack
+func TestPopAFFlags(t *testing.T) {
+	for value := range 256 {
+		// PUSH BC, POP AF, PUSH AF, POP DE
+		cpu, _ := newTestCPU(0xC5, 0xF1, 0xF5, 0xD1)
+		cpu.regs.b = 0x42
+		cpu.regs.c = reg(value)
+		cpu.regs.sp = 0xFFFE
+		for range 4 {
+			cpu.Step()
+		}
+
+		expected := toFlagReg(uint8(value))
+		if flags_comp := CompareFlagsRegister(cpu.regs.f, expected); flags_comp != nil {
+			t.Errorf("failed : pop af 0x%02X expected : %v got : %v", value, expected, cpu.regs.f)
+			t.Error(flags_comp.Error())
+		}
+		if cpu.regs.a != 0x42 {
+			t.Errorf("failed : pop af 0x%02X expected a : 0x42 got : 0x%02X", value, cpu.regs.a)
+		}
+		if cpu.regs.e != reg(value&0xF0) {
+			t.Errorf("failed : push af 0x%02X expected : 0x%02X got : 0x%02X", value, value&0xF0, cpu.regs.e)
+		}
+		if cpu.regs.getAF() != 0x4200|uint16(value&0xF0) {
+			t.Errorf("failed : af 0x%02X expected : 0x%04X got : 0x%04X", value, 0x4200|value&0xF0, cpu.regs.getAF())
+		}
+	}
+}
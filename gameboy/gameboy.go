@@ -0,0 +1,149 @@
+package gameboy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chilepikmin/gamegorl/cartridge"
+	"github.com/chilepikmin/gamegorl/cpu"
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+// CPUCore picks which cpu runs the game
+type CPUCore uint8
+
+const (
+	// cpu.CPU, runs a whole instruction at a time
+	InstructionCore CPUCore = iota
+	// cpu.MicroCPU, splits the instructions into m-cycles like the hardware
+	MicroCore
+)
+
+// Options says how the game boy is put together
+type Options struct {
+	// the post boot state, the boot rom size and what 0xFEA0-0xFEFF reads
+	// come from it
+	Model Model
+	// a dump of the boot rom of the model, without one the cpu and the
+	// hardware registers start where the boot rom would have left them
+	BootROM []uint8
+	Core    CPUCore
+	// where the battery save goes, next to the rom (see cartridge.SavePath)
+	// when empty, the cartridges without a battery dont have one
+	SavePath string
+}
+
+// GameBoy is the cpu, the bus and the cartridge wired together
+type GameBoy struct {
+	Model     Model
+	CPU       cpu.Core
+	Bus       *memory.Bus
+	Cartridge *cartridge.Cartridge
+	Mapper    cartridge.Mapper
+	// nil when nothing is saved, Close writes it
+	Save *cartridge.SaveFile
+	// mapped again on every Reset, nil to skip it
+	boot_rom []uint8
+}
+
+// New inserts the cartridge and either maps the boot rom at 0x0000 or skips
+// it by setting up the state it leaves behind
+func New(cart *cartridge.Cartridge, options Options) (*GameBoy, error) {
+	mapper, err := cartridge.NewMapper(cart)
+	if err != nil {
+		return nil, err
+	}
+	if options.Model >= MODELS {
+		return nil, fmt.Errorf("gameboy: unknown model %d", options.Model)
+	}
+	quirks := options.Model.quirks()
+	bus := memory.NewBus()
+	bus.SetUnusableReads(quirks.unusable)
+	cartridge.Insert(bus, mapper)
+	gb := &GameBoy{
+		Model:     options.Model,
+		CPU:       newCore(options.Core, bus),
+		Bus:       bus,
+		Cartridge: cart,
+		Mapper:    mapper,
+	}
+	if err := gb.openSave(options.SavePath); err != nil {
+		return nil, err
+	}
+	if options.BootROM != nil && len(options.BootROM) != quirks.boot_rom_size {
+		return nil, fmt.Errorf("gameboy: the %s boot rom is %d bytes, got %d", options.Model, quirks.boot_rom_size, len(options.BootROM))
+	}
+	gb.boot_rom = options.BootROM
+	if err := gb.boot(); err != nil {
+		return nil, err
+	}
+	return gb, nil
+}
+
+func newCore(core CPUCore, bus *memory.Bus) cpu.Core {
+	if core == MicroCore {
+		return cpu.NewMicro(bus)
+	}
+	return cpu.New(bus)
+}
+
+// loads the battery save and connects it to the cpu so it is written
+// periodically, a cartridge loaded from memory without a path has nowhere to
+// save to
+func (gb *GameBoy) openSave(path string) error {
+	if path == "" && gb.Cartridge.Path != "" {
+		path = cartridge.SavePath(gb.Cartridge.Path)
+	}
+	if path == "" {
+		return nil
+	}
+	save, err := cartridge.OpenSave(path, gb.Cartridge, gb.Mapper)
+	if errors.Is(err, cartridge.ErrNoBattery) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	gb.Save = save
+	gb.CPU.Connect(save)
+	return nil
+}
+
+// Close writes the battery save, it has to be called on shutdown
+func (gb *GameBoy) Close() error {
+	if gb.Save == nil {
+		return nil
+	}
+	return gb.Save.Close()
+}
+
+// Reset turns the game boy off and on again, the only way out of the lock up
+// of an illegal opcode, the cpu starts over from the boot rom or the post
+// boot state like after New, the cartridge and its ram are kept
+func (gb *GameBoy) Reset() {
+	gb.CPU.Reset()
+	gb.boot()
+}
+
+// what turning it on does, map the boot rom or leave what it would have
+func (gb *GameBoy) boot() error {
+	if gb.boot_rom != nil {
+		return gb.Bus.LoadBootROM(gb.boot_rom)
+	}
+	gb.skipBoot()
+	return nil
+}
+
+func (gb *GameBoy) skipBoot() {
+	gb.CPU.SetRegisters(postBootRegisters(gb.Model, gb.Cartridge))
+	for address, value := range postBootIO(gb.Model) {
+		gb.Bus.Write(address, value)
+	}
+	// IF is 0xE1, the vblank of the logo is still pending
+	gb.CPU.RequestInterrupt(cpu.VBlank)
+}
+
+// Step runs one instruction and returns the t-cycles it took
+func (gb *GameBoy) Step() uint8 {
+	return gb.CPU.Step()
+}
@@ -0,0 +1,342 @@
+package gameboy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chilepikmin/gamegorl/cartridge"
+	"github.com/chilepikmin/gamegorl/cpu"
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+// 32kb without a memory bank controller, the program starts at the entry
+// point
+func newTestCartridge(t *testing.T, title string, cgb_flag uint8, header_checksum uint8, program ...uint8) *cartridge.Cartridge {
+	rom := make([]uint8, 0x8000)
+	copy(rom[0x0100:], program)
+	copy(rom[cartridge.TITLE_ADDRESS:], title)
+	rom[cartridge.CGB_FLAG_ADDRESS] = cgb_flag
+	rom[cartridge.OLD_LICENSEE_ADDRESS] = 0x01
+	rom[cartridge.HEADER_CHECKSUM_ADDRESS] = header_checksum
+	cart, err := cartridge.Parse(rom)
+	if err != nil {
+		t.Fatalf("failed : %v", err)
+	}
+	return cart
+}
+
+type postBootTest struct {
+	title           string
+	model           Model
+	cart_title      string
+	cgb_flag        uint8
+	header_checksum uint8
+	af, bc, de, hl  uint16
+	io              map[uint16]uint8
+}
+
+func TestPostBoot(t *testing.T) {
+	tests := []postBootTest{
+		{title: "dmg", model: DMG, header_checksum: 0x42, af: 0x01B0, bc: 0x0013, de: 0x00D8, hl: 0x014D,
+			io: map[uint16]uint8{0xFF40: 0x91, 0xFF41: 0x85, 0xFF04: 0xAB, 0xFF26: 0xF1, 0xFF47: 0xFC, 0xFF46: 0xFF}},
+		{title: "dmg header checksum 0", model: DMG, af: 0x0180, bc: 0x0013, de: 0x00D8, hl: 0x014D},
+		{title: "dmg0", model: DMG0, header_checksum: 0x42, af: 0x0100, bc: 0xFF13, de: 0x00C1, hl: 0x8403,
+			io: map[uint16]uint8{0xFF04: 0x18, 0xFF41: 0x81, 0xFF4D: 0xFF}},
+		{title: "mgb", model: MGB, header_checksum: 0x42, af: 0xFFB0, bc: 0x0013, de: 0x00D8, hl: 0x014D,
+			io: map[uint16]uint8{0xFF04: 0xAB, 0xFF70: 0xFF}},
+		{title: "sgb", model: SGB, af: 0x0100, bc: 0x0014, de: 0x0000, hl: 0xC060,
+			io: map[uint16]uint8{0xFF26: 0xF0, 0xFF04: 0x00}},
+		{title: "sgb2", model: SGB2, header_checksum: 0x42, af: 0xFF00, bc: 0x0014, de: 0x0000, hl: 0xC060,
+			io: map[uint16]uint8{0xFF26: 0xF0}},
+		{title: "cgb", model: CGB, cgb_flag: 0x80, af: 0x1180, bc: 0x0000, de: 0xFF56, hl: 0x000D,
+			io: map[uint16]uint8{0xFF40: 0x91, 0xFF46: 0x00, 0xFF4D: 0x7E, 0xFF70: 0xF8}},
+		{title: "cgb running a dmg game", model: CGB, cart_title: "AB", af: 0x1180, bc: 0x8300, de: 0x0008, hl: 0x007C},
+		{title: "cgb running a dmg game with a special title", model: CGB, cart_title: "!\"", af: 0x1180, bc: 0x4300, de: 0x0008, hl: 0x991A},
+		{title: "cgb running a dmg game with a space padded title", model: CGB, cart_title: "Ab             ", af: 0x1180, bc: 0x4300, de: 0x0008, hl: 0x991A},
+		{title: "agb", model: AGB, cgb_flag: 0xC0, af: 0x1100, bc: 0x0100, de: 0xFF56, hl: 0x000D,
+			io: map[uint16]uint8{0xFF46: 0x00, 0xFF4F: 0xFE, 0xFF26: 0xF1}},
+		{title: "agb running a dmg game", model: AGB, cart_title: "AB", af: 0x1100, bc: 0x8400, de: 0x0008, hl: 0x007C},
+		{title: "agb half carry", model: AGB, cart_title: "\x0F", af: 0x1120, bc: 0x1000, de: 0x0008, hl: 0x007C},
+		{title: "agb zero", model: AGB, cart_title: "\x7F\x80", af: 0x11A0, bc: 0x0000, de: 0x0008, hl: 0x007C},
+	}
+
+	for _, unit_test := range tests {
+		for _, core := range []CPUCore{InstructionCore, MicroCore} {
+			if testPostBoot(t, unit_test, core) {
+				t.Logf("ok: %s core %d", unit_test.title, core)
+			}
+		}
+	}
+}
+
+// both cores have to start from the same state
+func testPostBoot(t *testing.T, unit_test postBootTest, core CPUCore) bool {
+	// LDH A, (0x0F) to see IF
+	cart := newTestCartridge(t, unit_test.cart_title, unit_test.cgb_flag, unit_test.header_checksum, 0xF0, 0x0F)
+	gb, err := New(cart, Options{Model: unit_test.model, Core: core})
+	if err != nil {
+		t.Fatalf("failed : %s %v", unit_test.title, err)
+	}
+	regs := gb.CPU.Registers()
+	if regs.AF() != unit_test.af || regs.BC() != unit_test.bc || regs.DE() != unit_test.de || regs.HL() != unit_test.hl || regs.PC() != 0x0100 || regs.SP() != 0xFFFE {
+		t.Errorf("failed : %s expected af : 0x%04X bc : 0x%04X de : 0x%04X hl : 0x%04X got af : 0x%04X bc : 0x%04X de : 0x%04X hl : 0x%04X pc : 0x%04X",
+			unit_test.title, unit_test.af, unit_test.bc, unit_test.de, unit_test.hl, regs.AF(), regs.BC(), regs.DE(), regs.HL(), regs.PC())
+		return false
+	}
+	success := true
+	for address, expected := range unit_test.io {
+		if value := gb.Bus.Read(address); value != expected {
+			t.Errorf("failed : %s 0x%04X expected : 0x%02X got : 0x%02X", unit_test.title, address, expected, value)
+			success = false
+		}
+	}
+	gb.Step()
+	if regs := gb.CPU.Registers(); regs.AF()>>8 != 0xE1 {
+		t.Errorf("failed : %s expected IF to be 0xE1 got : 0x%02X", unit_test.title, regs.AF()>>8)
+		success = false
+	}
+	return success
+}
+
+func TestBootROM(t *testing.T) {
+	// NOPs up to the end where it unmaps itself like the real one does
+	boot := make([]uint8, memory.BOOT_ROM_SIZE)
+	copy(boot[0xFC:], []uint8{0x3E, 0x01, 0xE0, 0x50})
+	// INC A at the entry point
+	cart := newTestCartridge(t, "", 0x00, 0x00, 0x3C)
+
+	gb, err := New(cart, Options{Model: DMG, BootROM: boot})
+	if err != nil {
+		t.Fatalf("failed : %v", err)
+	}
+	if regs := gb.CPU.Registers(); regs.PC() != 0x0000 || regs.AF() != 0x0000 || !gb.Bus.Booting() {
+		t.Fatalf("failed : expected the cpu to start at 0x0000 in the boot rom")
+	}
+	if value := gb.Bus.Read(0x00FC); value != 0x3E {
+		t.Errorf("failed : expected the boot rom at 0x00FC got : 0x%02X", value)
+	}
+	for gb.CPU.Registers().PC() != 0x0100 {
+		gb.Step()
+	}
+	if gb.Bus.Booting() || gb.Bus.Read(0x00FC) != 0x00 {
+		t.Errorf("failed : expected the cartridge after the write to 0xFF50")
+	}
+	gb.Step()
+	if regs := gb.CPU.Registers(); regs.AF()>>8 != 0x02 {
+		t.Errorf("failed : expected the cartridge to run after the boot rom got a : 0x%02X", regs.AF()>>8)
+	}
+
+	if _, err := New(cart, Options{BootROM: make([]uint8, 10)}); err == nil {
+		t.Errorf("failed : expected a boot rom of the wrong size to fail")
+	}
+}
+
+func TestModels(t *testing.T) {
+	type test struct {
+		name      string
+		model     Model
+		boot_size int
+		unusable  uint8
+	}
+
+	tests := []test{
+		{name: "dmg", model: DMG, boot_size: memory.BOOT_ROM_SIZE, unusable: 0x00},
+		{name: "DMG0", model: DMG0, boot_size: memory.BOOT_ROM_SIZE, unusable: 0x00},
+		{name: "Mgb", model: MGB, boot_size: memory.BOOT_ROM_SIZE, unusable: 0x00},
+		{name: "sgb", model: SGB, boot_size: memory.BOOT_ROM_SIZE, unusable: 0x00},
+		{name: "sgb2", model: SGB2, boot_size: memory.BOOT_ROM_SIZE, unusable: 0x00},
+		{name: "cgb", model: CGB, boot_size: memory.CGB_BOOT_ROM_SIZE, unusable: 0xBB},
+		{name: "agb", model: AGB, boot_size: memory.CGB_BOOT_ROM_SIZE, unusable: 0xBB},
+	}
+
+	cart := newTestCartridge(t, "", 0x80, 0x00)
+	for _, unit_test := range tests {
+		model, err := ParseModel(unit_test.name)
+		if err != nil || model != unit_test.model {
+			t.Errorf("failed : %s expected : %s got : %s %v", unit_test.name, unit_test.model, model, err)
+			continue
+		}
+		gb, err := New(cart, Options{Model: model})
+		if err != nil {
+			t.Fatalf("failed : %s %v", unit_test.name, err)
+		}
+		if value := gb.Bus.Read(0xFEB3); value != unit_test.unusable {
+			t.Errorf("failed : %s unusable area expected : 0x%02X got : 0x%02X", unit_test.name, unit_test.unusable, value)
+			continue
+		}
+		// the boot rom has to be the one of the model
+		if _, err := New(cart, Options{Model: model, BootROM: make([]uint8, unit_test.boot_size)}); err != nil {
+			t.Errorf("failed : %s expected a %d byte boot rom to work %v", unit_test.name, unit_test.boot_size, err)
+			continue
+		}
+		other := memory.CGB_BOOT_ROM_SIZE + memory.BOOT_ROM_SIZE - unit_test.boot_size
+		if _, err := New(cart, Options{Model: model, BootROM: make([]uint8, other)}); err == nil {
+			t.Errorf("failed : %s expected a %d byte boot rom to fail", unit_test.name, other)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.name)
+	}
+
+	if _, err := ParseModel("gba"); err == nil {
+		t.Errorf("failed : expected an unknown model to fail")
+	}
+	if _, err := New(cart, Options{Model: MODELS}); err == nil {
+		t.Errorf("failed : expected an unknown model to fail")
+	}
+}
+
+func TestCores(t *testing.T) {
+	type test struct {
+		title string
+		core  CPUCore
+		micro bool
+	}
+
+	tests := []test{
+		{title: "instruction core", core: InstructionCore},
+		{title: "micro core", core: MicroCore, micro: true},
+	}
+
+	// LD A, 0x12 - LD (0xC000), A
+	cart := newTestCartridge(t, "", 0x00, 0x00, 0x3E, 0x12, 0xEA, 0x00, 0xC0)
+	for _, unit_test := range tests {
+		gb, err := New(cart, Options{Core: unit_test.core})
+		if err != nil {
+			t.Fatalf("failed : %s %v", unit_test.title, err)
+		}
+		if _, micro := gb.CPU.(*cpu.MicroCPU); micro != unit_test.micro {
+			t.Errorf("failed : %s expected micro : %t", unit_test.title, unit_test.micro)
+			continue
+		}
+		cycles := int(gb.Step()) + int(gb.Step())
+		if cycles != 24 || gb.Bus.Read(0xC000) != 0x12 || gb.CPU.Registers().PC() != 0x0105 {
+			t.Errorf("failed : %s expected the program to run in 24 cycles got : %d", unit_test.title, cycles)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+}
+
+func TestSave(t *testing.T) {
+	directory := t.TempDir()
+	// MBC1+RAM+BATTERY with 8kb of ram
+	rom := make([]uint8, 0x8000)
+	rom[cartridge.TYPE_ADDRESS] = 0x03
+	rom[cartridge.RAM_SIZE_ADDRESS] = 0x02
+	// JR -2 forever
+	rom[0x0100], rom[0x0101] = 0x18, 0xFE
+	rom_path := filepath.Join(directory, "game.gb")
+	if err := os.WriteFile(rom_path, rom, 0o644); err != nil {
+		t.Fatalf("failed : %v", err)
+	}
+
+	type test struct {
+		title     string
+		save_path string
+		expected  string
+	}
+
+	tests := []test{
+		{title: "next to the rom", expected: filepath.Join(directory, "game.sav")},
+		{title: "somewhere else", save_path: filepath.Join(directory, "other.sav"), expected: filepath.Join(directory, "other.sav")},
+	}
+
+	for _, unit_test := range tests {
+		cart, err := cartridge.Load(rom_path)
+		if err != nil {
+			t.Fatalf("failed : %v", err)
+		}
+		gb, err := New(cart, Options{SavePath: unit_test.save_path})
+		if err != nil {
+			t.Fatalf("failed : %s %v", unit_test.title, err)
+		}
+		if gb.Save == nil || gb.Save.Path != unit_test.expected {
+			t.Errorf("failed : %s expected a save at %s", unit_test.title, unit_test.expected)
+			continue
+		}
+
+		// the cpu flushes it after a second of game boy time
+		gb.Bus.Write(0x0000, 0x0A)
+		gb.Bus.Write(0xA000, 0x42)
+		for cycles := 0; cycles < cartridge.SAVE_INTERVAL; cycles += int(gb.Step()) {
+		}
+		if data, err := os.ReadFile(unit_test.expected); err != nil || data[0] != 0x42 {
+			t.Errorf("failed : %s expected the periodic flush to write the save %v", unit_test.title, err)
+			continue
+		}
+
+		// and Close writes what changed since
+		gb.Bus.Write(0xA001, 0x43)
+		if err := gb.Close(); err != nil {
+			t.Fatalf("failed : %s close %v", unit_test.title, err)
+		}
+		gb, _ = New(cart, Options{SavePath: unit_test.save_path})
+		gb.Bus.Write(0x0000, 0x0A)
+		if gb.Bus.Read(0xA000) != 0x42 || gb.Bus.Read(0xA001) != 0x43 {
+			t.Errorf("failed : %s expected the ram to be loaded", unit_test.title)
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+
+	// without a path or a battery there is nothing to save
+	gb, _ := New(newTestCartridge(t, "", 0x00, 0x00), Options{})
+	if gb.Save != nil || gb.Close() != nil {
+		t.Errorf("failed : expected no save for a cartridge without a battery")
+	}
+}
+
+func TestReset(t *testing.T) {
+	type test struct {
+		title string
+		core  CPUCore
+		boot  bool
+	}
+
+	tests := []test{
+		{title: "instruction core", core: InstructionCore},
+		{title: "micro core", core: MicroCore},
+		{title: "instruction core with the boot rom", core: InstructionCore, boot: true},
+		{title: "micro core with the boot rom", core: MicroCore, boot: true},
+	}
+
+	boot := make([]uint8, memory.BOOT_ROM_SIZE)
+	copy(boot[0xFC:], []uint8{0x3E, 0x01, 0xE0, 0x50})
+	// XOR A - LDH (0x40), A to turn the lcd off and then an illegal opcode
+	cart := newTestCartridge(t, "", 0x00, 0x42, 0xAF, 0xE0, 0x40, 0xD3)
+	for _, unit_test := range tests {
+		options := Options{Core: unit_test.core}
+		if unit_test.boot {
+			options.BootROM = boot
+		}
+		gb, err := New(cart, options)
+		if err != nil {
+			t.Fatalf("failed : %s %v", unit_test.title, err)
+		}
+		for steps := 0; gb.CPU.Err() == nil && steps < 1000; steps++ {
+			gb.Step()
+		}
+		if gb.CPU.Err() == nil || gb.Bus.Read(0xFF40) != 0x00 {
+			t.Errorf("failed : %s expected the cpu to lock up", unit_test.title)
+			continue
+		}
+
+		gb.Reset()
+		regs := gb.CPU.Registers()
+		if gb.CPU.Err() != nil {
+			t.Errorf("failed : %s expected the reset to unlock the cpu", unit_test.title)
+			continue
+		}
+		if unit_test.boot {
+			if !gb.Bus.Booting() || regs.PC() != 0x0000 {
+				t.Errorf("failed : %s expected the boot rom to be mapped again got pc : 0x%04X", unit_test.title, regs.PC())
+				continue
+			}
+		} else if regs.AF() != 0x01B0 || regs.BC() != 0x0013 || regs.PC() != 0x0100 || regs.SP() != 0xFFFE || gb.Bus.Read(0xFF40) != 0x91 {
+			t.Errorf("failed : %s expected the post boot state got af : 0x%04X pc : 0x%04X lcdc : 0x%02X", unit_test.title, regs.AF(), regs.PC(), gb.Bus.Read(0xFF40))
+			continue
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+}
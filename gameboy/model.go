@@ -0,0 +1,193 @@
+package gameboy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chilepikmin/gamegorl/cartridge"
+	"github.com/chilepikmin/gamegorl/cpu"
+	"github.com/chilepikmin/gamegorl/memory"
+)
+
+// Model is the hardware revision being emulated, the games tell them apart
+// by the registers the boot rom leaves behind, DMG comes first so it is the
+// default
+type Model uint8
+
+const (
+	DMG Model = iota
+	// the first revision of the japanese game boy, its boot rom is different
+	DMG0
+	// game boy pocket and light
+	MGB
+	SGB
+	SGB2
+	CGB
+	// the game boy advance running game boy games
+	AGB
+
+	MODELS
+)
+
+func (model Model) String() string {
+	return [...]string{"DMG", "DMG0", "MGB", "SGB", "SGB2", "CGB", "AGB"}[model]
+}
+
+// ParseModel takes the name of a model in any case, for the frontends
+func ParseModel(name string) (Model, error) {
+	for model := range MODELS {
+		if strings.EqualFold(name, model.String()) {
+			return model, nil
+		}
+	}
+	return DMG, fmt.Errorf("gameboy: unknown model %q", name)
+}
+
+// CGB is true for the models with the color hardware
+func (model Model) CGB() bool {
+	return model == CGB || model == AGB
+}
+
+// the only differences between the models besides the post boot state for
+// now, the size of the boot rom and what the unusable area reads, the ones
+// in the ppu and the apu arent emulated
+type quirks struct {
+	// the cgb and agb boot roms are 0x900 bytes and the others 0x100
+	boot_rom_size int
+	unusable      memory.UnusableReads
+}
+
+func (model Model) quirks() quirks {
+	if model.CGB() {
+		return quirks{boot_rom_size: memory.CGB_BOOT_ROM_SIZE, unusable: memory.UnusableNibble}
+	}
+	return quirks{boot_rom_size: memory.BOOT_ROM_SIZE, unusable: memory.UnusableZero}
+}
+
+// the registers each boot rom leaves behind
+// https://gbdev.io/pandocs/Power_Up_Sequence.html#cpu-registers
+func postBootRegisters(model Model, cart *cartridge.Cartridge) cpu.Registers {
+	header := &cart.Header
+	switch model {
+	case DMG0:
+		{
+			return cpu.PostBootRegisters(0x0100, 0xFF13, 0x00C1, 0x8403)
+		}
+	case SGB:
+		{
+			return cpu.PostBootRegisters(0x0100, 0x0014, 0x0000, 0xC060)
+		}
+	case SGB2:
+		{
+			return cpu.PostBootRegisters(0xFF00, 0x0014, 0x0000, 0xC060)
+		}
+	case CGB, AGB:
+		{
+			return cgbPostBootRegisters(model, cart)
+		}
+	}
+	// half carry and carry are only clear when the header checksum is 0
+	f := uint16(0xB0)
+	if header.HeaderChecksum == 0x00 {
+		f = 0x80
+	}
+	a := uint16(0x01)
+	if model == MGB {
+		a = 0xFF
+	}
+	return cpu.PostBootRegisters(a<<8|f, 0x0013, 0x00D8, 0x014D)
+}
+
+// the agb boot rom is the cgb one with an INC B at the end, that is how the
+// games know they can use the brighter palettes, it also leaves its flags
+func cgbPostBootRegisters(model Model, cart *cartridge.Cartridge) cpu.Registers {
+	b, de, hl := uint8(0x00), uint16(0xFF56), uint16(0x000D)
+	if cart.Header.CGB() == cartridge.CGBNone {
+		b, hl = dmgModeB(cart)
+		de = 0x0008
+	}
+	f := uint8(0x80)
+	if model == AGB {
+		f = 0x00
+		if b&0x0F == 0x0F {
+			f |= 0x20
+		}
+		b++
+		if b == 0 {
+			f |= 0x80
+		}
+	}
+	return cpu.PostBootRegisters(0x1100|uint16(f), uint16(b)<<8, de, hl)
+}
+
+// the cgb boot rom picks a palette for the old games, for the ones made by
+// nintendo it adds up the title to look it up and that sum is left in b,
+// two of the sums also leave hl pointing somewhere else, it adds the 16 raw
+// bytes of the title area, padding and cgb flag included, not the trimmed
+// Header.Title
+func dmgModeB(cart *cartridge.Cartridge) (b uint8, hl uint16) {
+	header := &cart.Header
+	if header.OldLicensee == 0x01 || (header.OldLicensee == cartridge.USE_NEW_LICENSEE && header.NewLicensee == "01") {
+		for _, character := range cart.ROM[cartridge.TITLE_ADDRESS : cartridge.CGB_FLAG_ADDRESS+1] {
+			b += character
+		}
+	}
+	if b == 0x43 || b == 0x58 {
+		return b, 0x991A
+	}
+	return b, 0x007C
+}
+
+// the hardware registers after the dmg boot rom
+// https://gbdev.io/pandocs/Power_Up_Sequence.html#hardware-registers
+var dmgIO = map[uint16]uint8{
+	0xFF00: 0xCF, 0xFF01: 0x00, 0xFF02: 0x7E, 0xFF04: 0xAB, 0xFF05: 0x00,
+	0xFF06: 0x00, 0xFF07: 0xF8, 0xFF10: 0x80, 0xFF11: 0xBF, 0xFF12: 0xF3,
+	0xFF13: 0xFF, 0xFF14: 0xBF, 0xFF16: 0x3F, 0xFF17: 0x00, 0xFF18: 0xFF,
+	0xFF19: 0xBF, 0xFF1A: 0x7F, 0xFF1B: 0xFF, 0xFF1C: 0x9F, 0xFF1D: 0xFF,
+	0xFF1E: 0xBF, 0xFF20: 0xFF, 0xFF21: 0x00, 0xFF22: 0x00, 0xFF23: 0xBF,
+	0xFF24: 0x77, 0xFF25: 0xF3, 0xFF26: 0xF1, 0xFF40: 0x91, 0xFF41: 0x85,
+	0xFF42: 0x00, 0xFF43: 0x00, 0xFF44: 0x00, 0xFF45: 0x00, 0xFF46: 0xFF,
+	0xFF47: 0xFC, 0xFF4A: 0x00, 0xFF4B: 0x00,
+	// the cgb registers dont exist and read as ones
+	0xFF4D: 0xFF, 0xFF4F: 0xFF, 0xFF51: 0xFF, 0xFF52: 0xFF, 0xFF53: 0xFF,
+	0xFF54: 0xFF, 0xFF55: 0xFF, 0xFF56: 0xFF, 0xFF68: 0xFF, 0xFF69: 0xFF,
+	0xFF6A: 0xFF, 0xFF6B: 0xFF, 0xFF70: 0xFF,
+}
+
+// what each model changes from dmgIO
+var modelIO = [MODELS]map[uint16]uint8{
+	DMG0: {0xFF04: 0x18, 0xFF41: 0x81},
+	SGB:  {0xFF26: 0xF0},
+	SGB2: {0xFF26: 0xF0},
+	CGB: {
+		0xFF02: 0x7F, 0xFF46: 0x00, 0xFF4D: 0x7E, 0xFF4F: 0xFE, 0xFF56: 0x3E,
+		0xFF70: 0xF8,
+	},
+}
+
+// registers whose value depends on how long the boot rom took, they are
+// left alone
+var unknownIO = map[Model][]uint16{
+	SGB:  {0xFF04, 0xFF41},
+	SGB2: {0xFF04, 0xFF41},
+	CGB:  {0xFF04, 0xFF41, 0xFF68, 0xFF69, 0xFF6A, 0xFF6B},
+}
+
+func postBootIO(model Model) map[uint16]uint8 {
+	io := map[uint16]uint8{}
+	for address, value := range dmgIO {
+		io[address] = value
+	}
+	// the agb runs the cgb boot rom
+	if model == AGB {
+		model = CGB
+	}
+	for address, value := range modelIO[model] {
+		io[address] = value
+	}
+	for _, address := range unknownIO[model] {
+		delete(io, address)
+	}
+	return io
+}
@@ -0,0 +1,46 @@
+package memory
+
+import "fmt"
+
+const (
+	// writing anything other than 0 here unmaps the boot rom for good
+	BOOT_ROM_DISABLE = 0xFF50
+
+	// DMG, MGB and SGB boot roms
+	BOOT_ROM_SIZE = 0x100
+	// CGB and AGB boot roms, 0x0100-0x01FF is left to the cartridge header
+	// so the boot rom can read it
+	CGB_BOOT_ROM_SIZE = 0x900
+)
+
+// BootROMSizeError is returned for a boot rom that isnt 256 or 2304 bytes
+type BootROMSizeError struct {
+	Size int
+}
+
+func (err *BootROMSizeError) Error() string {
+	return fmt.Sprintf("memory: boot rom is %d bytes, expected %d or %d", err.Size, BOOT_ROM_SIZE, CGB_BOOT_ROM_SIZE)
+}
+
+// LoadBootROM maps the boot rom over the cartridge from 0x0000 until the
+// boot rom itself writes to 0xFF50
+func (b *Bus) LoadBootROM(rom []uint8) error {
+	if len(rom) != BOOT_ROM_SIZE && len(rom) != CGB_BOOT_ROM_SIZE {
+		return &BootROMSizeError{Size: len(rom)}
+	}
+	b.boot = rom
+	return nil
+}
+
+// Booting is true while the boot rom is mapped
+func (b *Bus) Booting() bool {
+	return b.boot != nil
+}
+
+// the addresses the boot rom covers, the cgb one skips the header
+func (b *Bus) inBootROM(address uint16) bool {
+	if b.boot == nil || int(address) >= len(b.boot) {
+		return false
+	}
+	return address < 0x100 || address >= 0x200
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBootROM(t *testing.T) {
+	type test struct {
+		title   string
+		size    int
+		address uint16
+		boot    bool
+	}
+
+	tests := []test{
+		{title: "dmg start", size: BOOT_ROM_SIZE, address: 0x0000, boot: true},
+		{title: "dmg end", size: BOOT_ROM_SIZE, address: 0x00FF, boot: true},
+		{title: "dmg header", size: BOOT_ROM_SIZE, address: 0x0100},
+		{title: "dmg past the end", size: BOOT_ROM_SIZE, address: 0x0200},
+		{title: "cgb start", size: CGB_BOOT_ROM_SIZE, address: 0x0000, boot: true},
+		{title: "cgb header", size: CGB_BOOT_ROM_SIZE, address: 0x014F},
+		{title: "cgb after the header", size: CGB_BOOT_ROM_SIZE, address: 0x0200, boot: true},
+		{title: "cgb end", size: CGB_BOOT_ROM_SIZE, address: 0x08FF, boot: true},
+		{title: "cgb past the end", size: CGB_BOOT_ROM_SIZE, address: 0x0900},
+	}
+
+	for _, unit_test := range tests {
+		bus := NewBus()
+		cartridge := make(ReadOnly, 0x8000)
+		for i := range cartridge {
+			cartridge[i] = 0xCA
+		}
+		bus.Map(ROM, cartridge)
+		boot := make([]uint8, unit_test.size)
+		for i := range boot {
+			boot[i] = 0xB0
+		}
+		if err := bus.LoadBootROM(boot); err != nil {
+			t.Fatalf("failed : %s %v", unit_test.title, err)
+		}
+
+		expected := uint8(0xCA)
+		if unit_test.boot {
+			expected = 0xB0
+		}
+		if value := bus.Read(unit_test.address); value != expected {
+			t.Errorf("failed : %s expected : 0x%02X got : 0x%02X", unit_test.title, expected, value)
+			continue
+		}
+
+		// writing 0 doesnt unmap it
+		bus.Write(BOOT_ROM_DISABLE, 0x00)
+		if !bus.Booting() {
+			t.Errorf("failed : %s expected the boot rom to stay after writing 0", unit_test.title)
+		}
+		bus.Write(BOOT_ROM_DISABLE, 0x01)
+		if value := bus.Read(unit_test.address); bus.Booting() || value != 0xCA {
+			t.Errorf("failed : %s expected the cartridge after 0xFF50 got : 0x%02X", unit_test.title, value)
+			continue
+		}
+		// and there is no way back
+		bus.Write(BOOT_ROM_DISABLE, 0x00)
+		if bus.Booting() {
+			t.Errorf("failed : %s expected the boot rom to stay unmapped", unit_test.title)
+		}
+		t.Logf("ok: %s", unit_test.title)
+	}
+
+	var size_err *BootROMSizeError
+	if err := NewBus().LoadBootROM(make([]uint8, 0x200)); !errors.As(err, &size_err) || size_err.Size != 0x200 {
+		t.Errorf("failed : expected a *BootROMSizeError got : %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package memory
+
+// anything that can be mapped on the bus (cartridge, ppu, timer, ram...), it
+// gets the full 16 bit address so it can decode its own registers
+type Device interface {
+	Read(address uint16) uint8
+	Write(address uint16, value uint8)
+}
+
+// a device that switches banks (the cartridge memory bank controllers) and
+// can tell which one is mapped at an address
+type Banked interface {
+	Bank(address uint16) uint16
+}
+
+// the areas of the memory map, each one is served by one device
+// https://gbdev.io/pandocs/Memory_Map.html
+type Region uint8
+
+const (
+	// 0x0000-0x7FFF, the cartridge rom, the upper half is usually banked
+	ROM Region = iota
+	// 0x8000-0x9FFF, tiles and tile maps
+	VRAM
+	// 0xA000-0xBFFF, the ram in the cartridge if it has any
+	ExternalRAM
+	// 0xC000-0xDFFF, 0xE000-0xFDFF mirrors it
+	WRAM
+	// 0xFE00-0xFE9F, sprite attributes
+	OAM
+	// 0xFF00-0xFF7F and 0xFFFF, the hardware registers
+	IO
+	// 0xFF80-0xFFFE
+	HRAM
+
+	REGIONS
+)
+
+const (
+	ROM_START          = 0x0000
+	VRAM_START         = 0x8000
+	EXTERNAL_RAM_START = 0xA000
+	WRAM_START         = 0xC000
+	ECHO_START         = 0xE000
+	OAM_START          = 0xFE00
+	UNUSABLE_START     = 0xFEA0
+	IO_START           = 0xFF00
+	HRAM_START         = 0xFF80
+	IE_ADDRESS         = 0xFFFF
+)
+
+// an address nothing answers to reads as all ones, the data lines are
+// pulled up
+const OPEN_BUS = 0xFF
+
+// Bus is the default memory map, it routes every access to the device mapped
+// in the region the address falls in, echo ram goes to WRAM 0x2000 bytes
+// below and the unusable area between OAM and IO reads as zero
+type Bus struct {
+	devices [REGIONS]Device
+	// the boot rom while it is mapped over the cartridge, see LoadBootROM
+	boot []uint8
+	// what the unusable area reads, see SetUnusableReads
+	unusable UnusableReads
+}
+
+// UnusableReads is what the area between OAM and IO reads, it depends on the
+// model
+type UnusableReads uint8
+
+const (
+	// DMG, MGB, SGB and the older CGB revisions
+	UnusableZero UnusableReads = iota
+	// CGB revision E and AGB repeat the high nibble of the lower byte of the
+	// address, 0xFEA0-0xFEAF reads 0xAA and 0xFEB0-0xFEBF 0xBB
+	UnusableNibble
+)
+
+func (b *Bus) SetUnusableReads(reads UnusableReads) {
+	b.unusable = reads
+}
+
+// NewBus returns a bus with plain ram in VRAM, WRAM, OAM, IO and HRAM so it
+// can run programs before the ppu and the rest of the hardware are mapped,
+// ROM and ExternalRAM are left empty until a cartridge is inserted
+func NewBus() *Bus {
+	bus := &Bus{}
+	bus.Map(VRAM, NewRAM(VRAM_START, 0x2000))
+	bus.Map(WRAM, NewRAM(WRAM_START, 0x2000))
+	bus.Map(OAM, NewRAM(OAM_START, 0xA0))
+	bus.Map(IO, NewRAM(IO_START, 0x100))
+	bus.Map(HRAM, NewRAM(HRAM_START, 0x7F))
+	return bus
+}
+
+// Map plugs the device into the region replacing whatever was there, a nil
+// device leaves the region unmapped
+func (b *Bus) Map(region Region, device Device) {
+	b.devices[region] = device
+}
+
+// Device returns what is mapped in the region, nil if nothing is
+func (b *Bus) Device(region Region) Device {
+	return b.devices[region]
+}
+
+// returns the region the address belongs to and the address the device has
+// to see, ok is false for the unusable area
+func decode(address uint16) (region Region, mapped uint16, ok bool) {
+	mapped = address
+	ok = true
+	switch {
+	case address < VRAM_START:
+		{
+			region = ROM
+		}
+	case address < EXTERNAL_RAM_START:
+		{
+			region = VRAM
+		}
+	case address < WRAM_START:
+		{
+			region = ExternalRAM
+		}
+	case address < ECHO_START:
+		{
+			region = WRAM
+		}
+	case address < OAM_START:
+		{
+			region = WRAM
+			mapped = address - (ECHO_START - WRAM_START)
+		}
+	case address < UNUSABLE_START:
+		{
+			region = OAM
+		}
+	case address < IO_START:
+		{
+			ok = false
+		}
+	case address < HRAM_START || address == IE_ADDRESS:
+		{
+			region = IO
+		}
+	default:
+		{
+			region = HRAM
+		}
+	}
+	return region, mapped, ok
+}
+
+func (b *Bus) Read(address uint16) uint8 {
+	if b.inBootROM(address) {
+		return b.boot[address]
+	}
+	region, mapped, ok := decode(address)
+	if !ok {
+		if b.unusable == UnusableNibble {
+			return uint8(address)&0xF0 | uint8(address)>>4&0x0F
+		}
+		return 0x00
+	}
+	device := b.devices[region]
+	if device == nil {
+		return OPEN_BUS
+	}
+	return device.Read(mapped)
+}
+
+func (b *Bus) Write(address uint16, value uint8) {
+	if address == BOOT_ROM_DISABLE && value != 0 {
+		b.boot = nil
+	}
+	region, mapped, ok := decode(address)
+	if !ok {
+		return
+	}
+	if device := b.devices[region]; device != nil {
+		device.Write(mapped, value)
+	}
+}
+
+// Bank asks the device mapped at the address which bank it is showing, the
+// cartridges with a memory bank controller know, anything else is assumed to
+// be 32kb of rom without banking
+func (b *Bus) Bank(address uint16) uint16 {
+	if b.inBootROM(address) {
+		return 0
+	}
+	region, mapped, ok := decode(address)
+	if ok {
+		if banked, is_banked := b.devices[region].(Banked); is_banked {
+			return banked.Bank(mapped)
+		}
+	}
+	if address >= 0x4000 && address < VRAM_START {
+		return 1
+	}
+	return 0
+}
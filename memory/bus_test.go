@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/chilepikmin/gamegorl/cpu"
+)
+
+var _ cpu.BankedBus = (*Bus)(nil)
+
+// remembers the last address it saw
+type recorder struct {
+	address uint16
+	written bool
+}
+
+func (r *recorder) Read(address uint16) uint8 {
+	r.address = address
+	return 0x42
+}
+
+func (r *recorder) Write(address uint16, value uint8) {
+	r.address = address
+	r.written = true
+}
+
+func TestBusRegions(t *testing.T) {
+	type test struct {
+		title   string
+		address uint16
+		region  Region
+		mapped  uint16
+	}
+
+	tests := []test{
+		{title: "rom bank 0", address: 0x0150, region: ROM, mapped: 0x0150},
+		{title: "rom bank n", address: 0x7FFF, region: ROM, mapped: 0x7FFF},
+		{title: "vram", address: 0x8000, region: VRAM, mapped: 0x8000},
+		{title: "external ram", address: 0xBFFF, region: ExternalRAM, mapped: 0xBFFF},
+		{title: "wram", address: 0xC000, region: WRAM, mapped: 0xC000},
+		{title: "echo ram", address: 0xE123, region: WRAM, mapped: 0xC123},
+		{title: "end of echo ram", address: 0xFDFF, region: WRAM, mapped: 0xDDFF},
+		{title: "oam", address: 0xFE9F, region: OAM, mapped: 0xFE9F},
+		{title: "io", address: 0xFF40, region: IO, mapped: 0xFF40},
+		{title: "ie", address: 0xFFFF, region: IO, mapped: 0xFFFF},
+		{title: "hram", address: 0xFF80, region: HRAM, mapped: 0xFF80},
+	}
+
+	for _, unit_test := range tests {
+		bus := &Bus{}
+		device := &recorder{}
+		bus.Map(unit_test.region, device)
+
+		success := true
+		if bus.Read(unit_test.address) != 0x42 || device.address != unit_test.mapped {
+			t.Errorf("failed : %s expected read at 0x%04X got : 0x%04X", unit_test.title, unit_test.mapped, device.address)
+			success = false
+		}
+		device.address = 0
+		bus.Write(unit_test.address, 0x01)
+		if !device.written || device.address != unit_test.mapped {
+			t.Errorf("failed : %s expected write at 0x%04X got : 0x%04X", unit_test.title, unit_test.mapped, device.address)
+			success = false
+		}
+		if success {
+			t.Logf("ok: %s", unit_test.title)
+		}
+	}
+}
+
+func TestBusUnmapped(t *testing.T) {
+	bus := &Bus{}
+	if value := bus.Read(0x4000); value != OPEN_BUS {
+		t.Errorf("failed : unmapped rom expected : 0x%02X got : 0x%02X", OPEN_BUS, value)
+	}
+	bus.Write(0xC000, 0x12)
+
+	bus = NewBus()
+	bus.Write(0xFEA0, 0x12)
+	if value := bus.Read(0xFEA0); value != 0x00 {
+		t.Errorf("failed : unusable area expected : 0x00 got : 0x%02X", value)
+	}
+
+	bus.SetUnusableReads(UnusableNibble)
+	for address, expected := range map[uint16]uint8{0xFEA0: 0xAA, 0xFEAF: 0xAA, 0xFEB5: 0xBB, 0xFEFF: 0xFF} {
+		if value := bus.Read(address); value != expected {
+			t.Errorf("failed : unusable area 0x%04X expected : 0x%02X got : 0x%02X", address, expected, value)
+		}
+	}
+}
+
+func TestBusEcho(t *testing.T) {
+	bus := NewBus()
+	bus.Write(0xC010, 0x12)
+	if value := bus.Read(0xE010); value != 0x12 {
+		t.Errorf("failed : echo expected : 0x12 got : 0x%02X", value)
+	}
+	bus.Write(0xFDFF, 0x34)
+	if value := bus.Read(0xDDFF); value != 0x34 {
+		t.Errorf("failed : echo write expected : 0x34 got : 0x%02X", value)
+	}
+}
+
+func TestBusRunsCPU(t *testing.T) {
+	bus := NewBus()
+	bus.Map(ROM, ReadOnly{
+		// LD HL,0xC000  LD (HL),0x42  LD A,(0xE000)  INC (HL)
+		0x21, 0x00, 0xC0, 0x36, 0x42, 0xFA, 0x00, 0xE0, 0x34,
+	})
+	core := cpu.New(bus)
+	for range 4 {
+		core.Step()
+	}
+	if bus.Read(0xC000) != 0x43 || bus.Read(0xE000) != 0x43 {
+		t.Errorf("failed : expected (0xC000) : 0x43 got : 0x%02X", bus.Read(0xC000))
+	}
+	// writes to rom dont change it
+	bus.Write(0x0000, 0x00)
+	if bus.Read(0x0000) != 0x21 {
+		t.Errorf("failed : rom expected to ignore writes")
+	}
+}
+
+// reports bank 5 in the switchable area
+type bankedROM struct {
+	ReadOnly
+}
+
+func (rom bankedROM) Bank(address uint16) uint16 {
+	if address < 0x4000 {
+		return 0
+	}
+	return 5
+}
+
+func TestBusBank(t *testing.T) {
+	bus := NewBus()
+	bus.Map(ROM, ReadOnly{})
+	if bus.Bank(0x0100) != 0 || bus.Bank(0x4100) != 1 {
+		t.Errorf("failed : plain rom expected banks 0 and 1")
+	}
+	bus.Map(ROM, bankedROM{})
+	if bus.Bank(0x4100) != 5 || bus.Bank(0x0100) != 0 {
+		t.Errorf("failed : banked rom expected bank 5 got : %d", bus.Bank(0x4100))
+	}
+}
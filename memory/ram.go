@@ -0,0 +1,41 @@
+package memory
+
+// RAM is a plain block of memory that starts at base, the addresses outside
+// of it read as OPEN_BUS
+type RAM struct {
+	base uint16
+	data []uint8
+}
+
+func NewRAM(base uint16, size int) *RAM {
+	return &RAM{base: base, data: make([]uint8, size)}
+}
+
+func (ram *RAM) Read(address uint16) uint8 {
+	offset := int(address - ram.base)
+	if offset >= len(ram.data) {
+		return OPEN_BUS
+	}
+	return ram.data[offset]
+}
+
+func (ram *RAM) Write(address uint16, value uint8) {
+	offset := int(address - ram.base)
+	if offset < len(ram.data) {
+		ram.data[offset] = value
+	}
+}
+
+// ReadOnly is memory starting at 0x0000 that ignores writes, a cartridge
+// without a memory bank controller is just this
+type ReadOnly []uint8
+
+func (rom ReadOnly) Read(address uint16) uint8 {
+	if int(address) >= len(rom) {
+		return OPEN_BUS
+	}
+	return rom[address]
+}
+
+func (rom ReadOnly) Write(address uint16, value uint8) {
+}